@@ -0,0 +1,293 @@
+package infoservice
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ==================== ACCESS LOG ====================
+
+var accessLogFormats = []string{"common", "combined", "json", "off"}
+
+// accessLogger writes one line per request once the response is done.
+type accessLogger struct {
+	format  string
+	exclude []string
+	// probeRate is the share of successful /health and /ready requests
+	// that are logged; failures always are.
+	probeRate float64
+	probes    atomic.Uint64
+	log       *logger
+	// file is where the lines go when ACCESS_LOG_FILE is set; nil means
+	// they go to log as "http" records.
+	file *rotatingFile
+}
+
+func newAccessLogger(cfg Config, log *logger, file *rotatingFile) *accessLogger {
+	return &accessLogger{
+		format:    cfg.AccessLogFormat,
+		exclude:   splitList(cfg.AccessLogExclude),
+		probeRate: cfg.AccessLogHealthSample,
+		log:       log,
+		file:      file,
+	}
+}
+
+func (a *accessLogger) middleware(next http.Handler) http.Handler {
+	if a.format == "off" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := a.log.now()
+		rec := &accessRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		if a.skip(r.URL.Path, rec.status) {
+			return
+		}
+		a.write(a.line(r, rec.status, rec.bytes, start, a.log.now().Sub(start)))
+	})
+}
+
+// skip reports whether a request is left out: excluded paths never show,
+// successful probes only at probeRate. Sampling counts probes instead of
+// drawing random numbers, so a rate of 0.1 logs exactly every tenth.
+func (a *accessLogger) skip(path string, status int) bool {
+	for _, pattern := range a.exclude {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if path == pattern {
+			return true
+		}
+	}
+	if (path == "/health" || path == "/ready") && status < 400 {
+		n := a.probes.Add(1)
+		return uint64(float64(n)*a.probeRate) == uint64(float64(n-1)*a.probeRate)
+	}
+	return false
+}
+
+func (a *accessLogger) write(line string) {
+	if a.file == nil {
+		a.log.infof("http", "%s", line)
+		return
+	}
+	if _, err := a.file.Write([]byte(line + "\n")); err != nil {
+		a.log.errorf("http", "Error writing access log: %v", err)
+	}
+}
+
+// accessEntry is one line of the json format.
+type accessEntry struct {
+	Time         string  `json:"time"`
+	RequestID    string  `json:"request_id,omitempty"`
+	RemoteAddr   string  `json:"remote_addr"`
+	ForwardedFor string  `json:"forwarded_for,omitempty"`
+	Method       string  `json:"method"`
+	URI          string  `json:"uri"`
+	Protocol     string  `json:"protocol"`
+	Status       int     `json:"status"`
+	Bytes        int64   `json:"bytes"`
+	DurationMS   float64 `json:"duration_ms"`
+	Referer      string  `json:"referer,omitempty"`
+	UserAgent    string  `json:"user_agent,omitempty"`
+}
+
+// clfTime is the timestamp layout of the Common Log Format.
+const clfTime = "02/Jan/2006:15:04:05 -0700"
+
+// line formats a request. common and combined follow Apache exactly, so
+// existing parsers read them; latency is only in json.
+func (a *accessLogger) line(r *http.Request, status int, bytes int64, start time.Time, elapsed time.Duration) string {
+	host := r.RemoteAddr
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	if a.format == "json" {
+		b, _ := json.Marshal(accessEntry{
+			Time:         start.UTC().Format(time.RFC3339Nano),
+			RequestID:    requestIDFrom(r.Context()),
+			RemoteAddr:   host,
+			ForwardedFor: r.Header.Get("X-Forwarded-For"),
+			Method:       r.Method,
+			URI:          r.RequestURI,
+			Protocol:     r.Proto,
+			Status:       status,
+			Bytes:        bytes,
+			DurationMS:   float64(elapsed.Microseconds()) / 1000,
+			Referer:      r.Referer(),
+			UserAgent:    r.UserAgent(),
+		})
+		return string(b)
+	}
+
+	size := "-"
+	if bytes > 0 {
+		size = strconv.FormatInt(bytes, 10)
+	}
+	line := fmt.Sprintf(`%s - - [%s] "%s %s %s" %d %s`,
+		host, start.Format(clfTime), r.Method, clfEscape(r.RequestURI), r.Proto, status, size)
+	if a.format == "combined" {
+		line += fmt.Sprintf(` "%s" "%s"`, clfField(r.Referer()), clfField(r.UserAgent()))
+	}
+	return line
+}
+
+var clfEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+
+func clfEscape(s string) string { return clfEscaper.Replace(s) }
+
+// clfField is an optional quoted field: "-" when empty.
+func clfField(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return clfEscape(s)
+}
+
+// accessRecorder captures the status and the bytes written after
+// compression, i.e. what went over the wire.
+type accessRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *accessRecorder) WriteHeader(code int) {
+	if w.status == 0 && code >= 200 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *accessRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Hijack records a WebSocket upgrade, which answers 101 on the raw
+// connection where WriteHeader never sees it.
+func (w *accessRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *accessRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// ==================== LOG FILE ROTATION ====================
+
+// rotatingFile appends to path and, before a write would take it past
+// maxSize, renames it to path.1, shifting older copies to path.2 and so on
+// up to maxBackups.
+type rotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+func openRotatingFile(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	rf := &rotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+func (rf *rotatingFile) open() error {
+	f, err := os.OpenFile(rf.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	rf.f, rf.size = f, info.Size()
+	return nil
+}
+
+func (rf *rotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	if rf.f == nil {
+		return 0, os.ErrClosed
+	}
+	if rf.size > 0 && rf.size+int64(len(p)) > rf.maxSize {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := rf.f.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+// rotate shifts the files and starts a new one. If shifting fails the
+// current file is reopened, so logging goes on and the next write retries.
+func (rf *rotatingFile) rotate() error {
+	if err := rf.f.Close(); err != nil {
+		return err
+	}
+	rf.f = nil
+	err := rf.shift()
+	if openErr := rf.open(); openErr != nil {
+		return openErr
+	}
+	return err
+}
+
+func (rf *rotatingFile) shift() error {
+	if rf.maxBackups == 0 {
+		return os.Remove(rf.path)
+	}
+	for i := rf.maxBackups - 1; i >= 1; i-- {
+		if err := os.Rename(rf.backup(i), rf.backup(i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Rename(rf.path, rf.backup(1))
+}
+
+func (rf *rotatingFile) backup(i int) string {
+	return rf.path + "." + strconv.Itoa(i)
+}
+
+func (rf *rotatingFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	if rf.f == nil {
+		return nil
+	}
+	err := rf.f.Close()
+	rf.f = nil
+	return err
+}
@@ -0,0 +1,206 @@
+package infoservice
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func accessRequest() *http.Request {
+	req := httptest.NewRequest("GET", "/?format=json", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.Header.Set("User-Agent", `curl/8.0 "quoted"`)
+	return req
+}
+
+func TestAccessLogger_Formats(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.FixedZone("", 3*3600))
+	testCases := []struct {
+		format string
+		want   string
+	}{
+		{"common", `192.0.2.1 - - [01/Mar/2026:12:00:00 +0300] "GET /?format=json HTTP/1.1" 200 512`},
+		{"combined", `192.0.2.1 - - [01/Mar/2026:12:00:00 +0300] "GET /?format=json HTTP/1.1" 200 512 "-" "curl/8.0 \"quoted\""`},
+	}
+	for _, tc := range testCases {
+		a := &accessLogger{format: tc.format}
+		if got := a.line(accessRequest(), 200, 512, start, 1500*time.Microsecond); got != tc.want {
+			t.Errorf("%s:\n got %s\nwant %s", tc.format, got, tc.want)
+		}
+	}
+
+	a := &accessLogger{format: "common"}
+	if got := a.line(accessRequest(), 304, 0, start, 0); !strings.HasSuffix(got, `" 304 -`) {
+		t.Errorf("empty body should log size -, got %s", got)
+	}
+
+	a = &accessLogger{format: "json"}
+	var entry accessEntry
+	if err := json.Unmarshal([]byte(a.line(accessRequest(), 404, 80, start, 1500*time.Microsecond)), &entry); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	want := accessEntry{
+		Time: "2026-03-01T09:00:00Z", RemoteAddr: "192.0.2.1", Method: "GET", URI: "/?format=json",
+		Protocol: "HTTP/1.1", Status: 404, Bytes: 80, DurationMS: 1.5, UserAgent: `curl/8.0 "quoted"`,
+	}
+	if entry != want {
+		t.Errorf("json entry = %+v, want %+v", entry, want)
+	}
+}
+
+func TestAccessLogger_Skip(t *testing.T) {
+	a := &accessLogger{exclude: []string{"/metrics", "/admin/*"}, probeRate: 0.25}
+	for path, want := range map[string]bool{"/metrics": true, "/metrics/x": false, "/admin/logs": true, "/": false} {
+		if got := a.skip(path, 200); got != want {
+			t.Errorf("skip(%s) = %v, want %v", path, got, want)
+		}
+	}
+
+	logged := 0
+	for i := 0; i < 8; i++ {
+		if !a.skip("/health", 200) {
+			logged++
+		}
+	}
+	if logged != 2 {
+		t.Errorf("logged %d of 8 probes at rate 0.25, want 2", logged)
+	}
+	if a.skip("/ready", 503) {
+		t.Error("failed probes must always be logged")
+	}
+
+	none := &accessLogger{probeRate: 0}
+	for i := 0; i < 5; i++ {
+		if !none.skip("/health", 200) {
+			t.Fatal("rate 0 logged a probe")
+		}
+	}
+}
+
+func TestAccessLogger_Middleware(t *testing.T) {
+	lines, printf := captureLogs()
+	log := newLogger(printf, nil)
+	cfg := DefaultConfig()
+	cfg.AccessLogFormat = "common"
+	h := newAccessLogger(cfg, log, nil).middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/created" {
+			w.WriteHeader(http.StatusCreated)
+		}
+		w.Write([]byte("hello"))
+	}))
+
+	for _, path := range []string{"/created", "/plain"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	if len(*lines) != 2 {
+		t.Fatalf("logged %d lines, want 2: %q", len(*lines), *lines)
+	}
+	if !strings.HasPrefix((*lines)[0], "[INFO] http: ") || !strings.HasSuffix((*lines)[0], `"GET /created HTTP/1.1" 201 5`) {
+		t.Errorf("line = %q", (*lines)[0])
+	}
+	if !strings.HasSuffix((*lines)[1], `"GET /plain HTTP/1.1" 200 5`) {
+		t.Errorf("line = %q", (*lines)[1])
+	}
+
+	cfg.AccessLogFormat = "off"
+	*lines = nil
+	newAccessLogger(cfg, log, nil).middleware(h).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/plain", nil))
+	if len(*lines) != 1 {
+		t.Errorf("format off added lines: %q", *lines)
+	}
+}
+
+func TestAccessLogger_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	f, err := openRotatingFile(path, 1<<20, 1)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+	lines, printf := captureLogs()
+
+	cfg := DefaultConfig()
+	cfg.AccessLogFormat = "json"
+	h := newAccessLogger(cfg, newLogger(printf, nil), f).middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/visits", nil))
+
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), `"uri":"/visits"`) || !strings.HasSuffix(string(data), "\n") {
+		t.Errorf("file = %q", data)
+	}
+	if len(*lines) != 0 {
+		t.Errorf("file output also went to the service log: %q", *lines)
+	}
+}
+
+func TestAccessLogger_WebSocketUpgrade(t *testing.T) {
+	s, addr := withWSHub(t, DefaultConfig())
+	records := make(chan logRecord, 16)
+	defer s.log.addSink(func(rec logRecord) { records <- rec })()
+
+	client, _ := dialWS(t, addr, nil)
+	client.conn.Close()
+
+	for {
+		select {
+		case rec := <-records:
+			if strings.Contains(rec.Message, `"GET /ws HTTP/1.1" 101`) {
+				return
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("no access log line with status 101 for the upgrade")
+		}
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "access.log")
+	f, err := openRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	for name, want := range map[string]string{"access.log": "fourth\n", "access.log.1": "third\n", "access.log.2": "second\n"} {
+		if data, _ := os.ReadFile(filepath.Join(dir, name)); string(data) != want {
+			t.Errorf("%s = %q, want %q", name, data, want)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Error("more backups kept than maxBackups")
+	}
+
+	// A line larger than maxSize still goes into a fresh file.
+	f.Write([]byte("a rather long line\n"))
+	if data, _ := os.ReadFile(path); string(data) != "a rather long line\n" {
+		t.Errorf("access.log = %q", data)
+	}
+}
+
+func TestRotatingFile_NoBackups(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "access.log")
+	f, _ := openRotatingFile(path, 8, 0)
+	defer f.Close()
+	f.Write([]byte("old line\n"))
+	f.Write([]byte("new\n"))
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("files = %d, want only access.log", len(entries))
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new\n" {
+		t.Errorf("access.log = %q", data)
+	}
+}
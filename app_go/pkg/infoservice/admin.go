@@ -0,0 +1,28 @@
+package infoservice
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// ==================== ADMIN API ====================
+
+// requireAdmin guards admin routes with a bearer token. Without ADMIN_TOKEN
+// the admin API is off: it exposes logs and changes behaviour at runtime,
+// so it must never be open by accident.
+func requireAdmin(token string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token == "" {
+			writeJSONError(w, r, http.StatusForbidden, "Admin API is disabled, set ADMIN_TOKEN to enable it")
+			return
+		}
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			writeJSONError(w, r, http.StatusUnauthorized, "Missing or invalid admin token")
+			return
+		}
+		next(w, r)
+	}
+}
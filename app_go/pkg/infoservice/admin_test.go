@@ -0,0 +1,59 @@
+package infoservice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }
+
+	testCases := []struct {
+		name   string
+		token  string
+		header string
+		want   int
+	}{
+		{"disabled without a token", "", "Bearer anything", http.StatusForbidden},
+		{"missing header", "s3cret", "", http.StatusUnauthorized},
+		{"wrong token", "s3cret", "Bearer guess", http.StatusUnauthorized},
+		{"wrong scheme", "s3cret", "Basic s3cret", http.StatusUnauthorized},
+		{"valid", "s3cret", "Bearer s3cret", http.StatusNoContent},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/admin/logs", nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			w := httptest.NewRecorder()
+			requireAdmin(tc.token, ok)(w, req)
+			if w.Code != tc.want {
+				t.Errorf("status = %d, want %d", w.Code, tc.want)
+			}
+			if w.Code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 without WWW-Authenticate")
+			}
+		})
+	}
+}
+
+func TestAdminRoutes_GuardedAndUnlisted(t *testing.T) {
+	h := newTestServer(t).Handler()
+	for _, rt := range newTestServer(t).routes() {
+		if !rt.Admin {
+			continue
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(rt.Method, rt.Path, nil))
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s without ADMIN_TOKEN: status = %d, want 403", rt.Path, w.Code)
+		}
+		for _, ep := range newTestServer(t).endpointList() {
+			if ep.Path == rt.Path {
+				t.Errorf("%s is listed in the public endpoints", rt.Path)
+			}
+		}
+	}
+}
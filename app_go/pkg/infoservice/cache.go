@@ -0,0 +1,87 @@
+package infoservice
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ==================== CONDITIONAL GET ====================
+
+// Cache-Control per endpoint. Both allow caches to store the response but
+// force revalidation, so pollers get a cheap 304 instead of a full body. The
+// info page echoes the client's IP and User-Agent, hence private.
+const (
+	cacheControlInfo   = "private, no-cache"
+	cacheControlHealth = "no-cache"
+)
+
+// wantsVolatile reads the ?volatile= query parameter. Volatile fields
+// (timestamps, uptime) change every second, so a client that wants 304s has
+// to ask for them to be left out with ?volatile=false.
+func wantsVolatile(r *http.Request) (bool, error) {
+	raw := r.URL.Query().Get("volatile")
+	if raw == "" {
+		return true, nil
+	}
+	return strconv.ParseBool(raw)
+}
+
+// responseBody returns v as is, or as a tree without its volatile fields.
+func responseBody(v interface{}, volatile bool) interface{} {
+	if volatile {
+		return v
+	}
+	return toTree(v, treeOptions{skipVolatile: true})
+}
+
+// writeCacheable renders v with f, tags it with an ETag computed over the
+// rendered bytes and answers 304 Not Modified when If-None-Match already
+// names that tag.
+func (s *Server) writeCacheable(w http.ResponseWriter, r *http.Request, f responseFormat, v interface{}, cacheControl string) {
+	body, err := renderBody(f, v)
+	if err != nil {
+		s.log.errorf("http", "Error rendering %s: %v", f.name, err)
+		writeJSONError(w, r, http.StatusInternalServerError, "Failed to render response")
+		return
+	}
+
+	etag := computeETag(body)
+	h := w.Header()
+	h.Set("ETag", etag)
+	h.Set("Cache-Control", cacheControl)
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	h.Set("Content-Type", f.contentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// computeETag hashes the representation. The tag is weak because the
+// compression middleware may change the bytes on the wire.
+func computeETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `W/"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// etagMatches implements the weak comparison If-None-Match requires: "*"
+// matches anything and the W/ prefix is ignored on both sides.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package infoservice
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// withClock returns a Server whose clock reads now after running for
+// uptime, so consecutive requests differ only in the volatile fields we
+// move between them.
+func withClock(t *testing.T, now time.Time, uptime time.Duration, opts ...Option) *Server {
+	t.Helper()
+	clock := newFakeClock(now.Add(-uptime))
+	s := newTestServer(t, append([]Option{WithClock(clock)}, opts...)...)
+	s.markStarted()
+	clock.Advance(uptime)
+	return s
+}
+
+func serveGet(h http.HandlerFunc, target string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", target, nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	h(w, req)
+	return w
+}
+
+func TestETag_StableWithoutVolatileFields(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name    string
+		handler func(*Server) http.HandlerFunc
+		target  string
+	}{
+		{"info", func(s *Server) http.HandlerFunc { return s.mainHandler }, "/"},
+		{"health", func(s *Server) http.HandlerFunc { return s.healthHandler }, "/health"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := tc.handler(withClock(t, start, time.Hour))
+			first := serveGet(h, tc.target+"?volatile=false", nil)
+			firstFull := serveGet(h, tc.target, nil)
+
+			h = tc.handler(withClock(t, start.Add(time.Minute), time.Hour+time.Minute))
+			second := serveGet(h, tc.target+"?volatile=false", nil)
+			secondFull := serveGet(h, tc.target, nil)
+
+			if first.Header().Get("ETag") == "" {
+				t.Fatal("missing ETag header")
+			}
+			if first.Header().Get("ETag") != second.Header().Get("ETag") {
+				t.Error("ETag changed although only volatile fields changed")
+			}
+			if firstFull.Header().Get("ETag") == secondFull.Header().Get("ETag") {
+				t.Error("ETag of the full response should change with the clock")
+			}
+		})
+	}
+}
+
+func TestConditionalGet_NotModified(t *testing.T) {
+	srv := withClock(t, time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC), time.Hour)
+
+	first := serveGet(srv.mainHandler, "/?volatile=false", nil)
+	etag := first.Header().Get("ETag")
+
+	testCases := []struct {
+		name        string
+		ifNoneMatch string
+		want        int
+	}{
+		{"exact", etag, http.StatusNotModified},
+		{"strong form", etag[2:], http.StatusNotModified},
+		{"in list", `"other", ` + etag, http.StatusNotModified},
+		{"star", "*", http.StatusNotModified},
+		{"stale", `W/"0000000000000000"`, http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := serveGet(srv.mainHandler, "/?volatile=false", map[string]string{"If-None-Match": tc.ifNoneMatch})
+			if w.Code != tc.want {
+				t.Fatalf("status = %d, want %d", w.Code, tc.want)
+			}
+			if w.Header().Get("ETag") != etag {
+				t.Errorf("ETag = %s, want %s", w.Header().Get("ETag"), etag)
+			}
+			if tc.want == http.StatusNotModified && w.Body.Len() != 0 {
+				t.Errorf("304 must not have a body, got %q", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestETag_DiffersPerFormat(t *testing.T) {
+	srv := withClock(t, time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC), time.Hour)
+
+	jsonTag := serveGet(srv.mainHandler, "/?volatile=false", nil).Header().Get("ETag")
+	yamlTag := serveGet(srv.mainHandler, "/?volatile=false&format=yaml", nil).Header().Get("ETag")
+	if jsonTag == yamlTag {
+		t.Error("JSON and YAML representations must not share an ETag")
+	}
+}
+
+func TestCacheControl(t *testing.T) {
+	if got := serveGet(newTestServer(t).mainHandler, "/", nil).Header().Get("Cache-Control"); got != cacheControlInfo {
+		t.Errorf("info Cache-Control = %q, want %q", got, cacheControlInfo)
+	}
+	if got := serveGet(newTestServer(t).healthHandler, "/health", nil).Header().Get("Cache-Control"); got != cacheControlHealth {
+		t.Errorf("health Cache-Control = %q, want %q", got, cacheControlHealth)
+	}
+}
+
+func TestVolatileFieldsExcluded(t *testing.T) {
+	w := serveGet(newTestServer(t).healthHandler, "/health?volatile=false", nil)
+	var health map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&health); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if _, ok := health["timestamp"]; ok {
+		t.Error("timestamp should be excluded")
+	}
+	if health["status"] != "healthy" {
+		t.Errorf("status = %v, want healthy", health["status"])
+	}
+
+	w = serveGet(newTestServer(t).mainHandler, "/?volatile=0", nil)
+	var info map[string]map[string]interface{}
+	json.NewDecoder(w.Body).Decode(&info)
+	for _, field := range []string{"uptime_seconds", "uptime_human", "current_time"} {
+		if _, ok := info["runtime"][field]; ok {
+			t.Errorf("runtime.%s should be excluded", field)
+		}
+	}
+	if _, ok := info["runtime"]["timezone"]; !ok {
+		t.Error("runtime.timezone is not volatile and must stay")
+	}
+}
+
+func TestVolatile_Invalid(t *testing.T) {
+	srv := newTestServer(t)
+	for _, h := range []http.HandlerFunc{srv.mainHandler, srv.healthHandler} {
+		w := serveGet(h, "/?volatile=sometimes", nil)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want 400", w.Code)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package infoservice
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ==================== CHAOS MODE ====================
+
+// Chaos mode makes a canary look bad on purpose, so automatic rollback can
+// be tested. It is off unless CHAOS_ENABLED is set; admin routes are never
+// affected, so it can always be turned off again.
+
+// chaosMaxLatency caps injected latency, so a header cannot hold a
+// connection for longer than a slow client could anyway.
+const chaosMaxLatency = 30 * time.Second
+
+var chaosFaultsTotal = newCounterVec("devops_chaos_faults_total", "Faults injected by chaos mode, by kind.", "fault", "latency", "error")
+
+type chaosState struct {
+	mu    sync.RWMutex
+	rules []ChaosRule
+	// rand rolls against error rates; tests make it deterministic.
+	rand func() float64
+}
+
+func newChaosState(rules []ChaosRule, rand func() float64) *chaosState {
+	return &chaosState{rules: rules, rand: rand}
+}
+
+func (c *chaosState) Rules() []ChaosRule {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]ChaosRule{}, c.rules...)
+}
+
+func (c *chaosState) SetRules(rules []ChaosRule) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rules = rules
+}
+
+// rule returns the rule for route: an exact match, else the "*" rule.
+func (c *chaosState) rule(route string) (ChaosRule, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var wildcard *ChaosRule
+	for i, rule := range c.rules {
+		if rule.Route == route {
+			return rule, true
+		}
+		if rule.Route == "*" && wildcard == nil {
+			wildcard = &c.rules[i]
+		}
+	}
+	if wildcard != nil {
+		return *wildcard, true
+	}
+	return ChaosRule{}, false
+}
+
+// chaosFault is what to do to one request.
+type chaosFault struct {
+	latency time.Duration
+	status  int
+}
+
+// faultFor combines the route's rule with the X-Chaos-Latency and
+// X-Chaos-Status headers, which take precedence.
+func (c *chaosState) faultFor(route string, r *http.Request) (chaosFault, error) {
+	var f chaosFault
+	if rule, ok := c.rule(route); ok {
+		f.latency, _ = time.ParseDuration(rule.Latency)
+		if rule.ErrorRate > 0 && c.rand() < rule.ErrorRate {
+			f.status = rule.status()
+		}
+	}
+
+	if v := r.Header.Get("X-Chaos-Latency"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 || d > chaosMaxLatency {
+			return f, fmt.Errorf("invalid X-Chaos-Latency %q: want a duration up to %s", v, chaosMaxLatency)
+		}
+		f.latency = d
+	}
+	if v := r.Header.Get("X-Chaos-Status"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 400 || n > 599 {
+			return f, fmt.Errorf("invalid X-Chaos-Status %q: want a status between 400 and 599", v)
+		}
+		f.status = n
+	}
+	return f, nil
+}
+
+// chaosFaults injects the faults chaos mode has set up for route: latency
+// first, then an error instead of the real response.
+func (s *Server) chaosFaults(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c := s.chaos
+		if c == nil {
+			next(w, r)
+			return
+		}
+		f, err := c.faultFor(route, r)
+		if err != nil {
+			writeJSONError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if f.latency > 0 {
+			chaosFaultsTotal.inc("latency")
+			logChaos(s.log, r, "latency", f.latency.String())
+			w.Header().Add("X-Chaos-Injected", "latency="+f.latency.String())
+			timer := time.NewTimer(f.latency)
+			select {
+			case <-timer.C:
+			case <-r.Context().Done():
+				timer.Stop()
+				return
+			}
+		}
+		if f.status != 0 {
+			chaosFaultsTotal.inc("error")
+			logChaos(s.log, r, "error", strconv.Itoa(f.status))
+			w.Header().Add("X-Chaos-Injected", "status="+strconv.Itoa(f.status))
+			writeJSONError(w, r, f.status, "Injected by chaos mode")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// logChaos marks an injected fault in the logs, so it is not mistaken for
+// a real one.
+func logChaos(l *logger, r *http.Request, fault, value string) {
+	l.log(levelWarn, "http", map[string]interface{}{
+		"chaos":      fault,
+		"request_id": requestIDFrom(r.Context()),
+	}, "Chaos: injected %s %s into %s %s", fault, value, r.Method, r.URL.Path)
+}
+
+func (rule ChaosRule) status() int {
+	if rule.Status == 0 {
+		return http.StatusInternalServerError
+	}
+	return rule.Status
+}
+
+func (rule ChaosRule) validate() error {
+	if rule.Route != "*" && !strings.HasPrefix(rule.Route, "/") {
+		return fmt.Errorf("route %q: want a path such as /health, or * for every route", rule.Route)
+	}
+	if rule.Latency != "" {
+		d, err := time.ParseDuration(rule.Latency)
+		if err != nil || d < 0 || d > chaosMaxLatency {
+			return fmt.Errorf("route %s: invalid latency %q, want a duration up to %s", rule.Route, rule.Latency, chaosMaxLatency)
+		}
+	}
+	if rule.ErrorRate < 0 || rule.ErrorRate > 1 {
+		return fmt.Errorf("route %s: invalid error_rate %g, want 0 to 1", rule.Route, rule.ErrorRate)
+	}
+	if rule.Status != 0 && (rule.Status < 400 || rule.Status > 599) {
+		return fmt.Errorf("route %s: invalid status %d, want 400 to 599", rule.Route, rule.Status)
+	}
+	if rule.Status != 0 && rule.ErrorRate == 0 {
+		return fmt.Errorf("route %s: status needs an error_rate", rule.Route)
+	}
+	return nil
+}
+
+// parseChaosRules reads CHAOS_RULES, a JSON array of rules.
+func parseChaosRules(s string) ([]ChaosRule, error) {
+	rules := []ChaosRule{}
+	if strings.TrimSpace(s) == "" {
+		return rules, nil
+	}
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&rules); err != nil {
+		return nil, err
+	}
+	return rules, checkChaosRules(rules)
+}
+
+func checkChaosRules(rules []ChaosRule) error {
+	seen := map[string]bool{}
+	for _, rule := range rules {
+		if err := rule.validate(); err != nil {
+			return err
+		}
+		if seen[rule.Route] {
+			return fmt.Errorf("route %s: more than one rule", rule.Route)
+		}
+		seen[rule.Route] = true
+	}
+	return nil
+}
+
+func (s *Server) chaosHandler(w http.ResponseWriter, r *http.Request) {
+	c := s.chaos
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		if c == nil {
+			writeJSONError(w, r, http.StatusConflict, "Chaos mode is disabled, set CHAOS_ENABLED=true to enable it")
+			return
+		}
+		var req ChaosUpdate
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			writeJSONError(w, r, http.StatusBadRequest, "Invalid JSON body: "+err.Error())
+			return
+		}
+		if req.Rules == nil {
+			req.Rules = []ChaosRule{}
+		}
+		if err := checkChaosRules(req.Rules); err != nil {
+			writeJSONError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		c.SetRules(req.Rules)
+		s.log.warnf("server", "Chaos rules replaced, %d active", len(req.Rules))
+	default:
+		writeJSONError(w, r, http.StatusMethodNotAllowed, "Only GET and PUT methods are allowed for this endpoint")
+		return
+	}
+
+	resp := ChaosResp{Rules: []ChaosRule{}}
+	if c != nil {
+		resp.Enabled, resp.Rules = true, c.Rules()
+	}
+	s.writeFormatted(w, r, responseFormats[0], http.StatusOK, resp)
+}
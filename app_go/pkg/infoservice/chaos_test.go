@@ -0,0 +1,179 @@
+package infoservice
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withChaos turns chaos mode on for s with rules, rolling with s's
+// chaosRand.
+func withChaos(s *Server, rules ...ChaosRule) *chaosState {
+	s.chaos = newChaosState(rules, s.chaosRand)
+	return s.chaos
+}
+
+func okHandler(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("ok"))
+}
+
+func TestParseChaosRules(t *testing.T) {
+	rules, err := parseChaosRules(`[{"route": "/", "error_rate": 0.5, "status": 503}, {"route": "*", "latency": "200ms"}]`)
+	if err != nil || len(rules) != 2 || rules[0].Status != 503 || rules[1].Latency != "200ms" {
+		t.Fatalf("parseChaosRules = (%+v, %v)", rules, err)
+	}
+	if rules, err := parseChaosRules(""); err != nil || rules == nil || len(rules) != 0 {
+		t.Errorf("empty = (%v, %v), want no rules", rules, err)
+	}
+
+	for _, bad := range []string{
+		`{"route": "/"}`,
+		`[{"route": "health", "error_rate": 1}]`,
+		`[{"route": "/", "latency": "soon"}]`,
+		`[{"route": "/", "latency": "1h"}]`,
+		`[{"route": "/", "error_rate": 2}]`,
+		`[{"route": "/", "error_rate": 1, "status": 200}]`,
+		`[{"route": "/", "status": 503}]`,
+		`[{"route": "/", "error_rate": 1}, {"route": "/", "latency": "1s"}]`,
+		`[{"route": "/", "rate": 1}]`,
+	} {
+		if _, err := parseChaosRules(bad); err == nil {
+			t.Errorf("%s: expected an error", bad)
+		}
+	}
+}
+
+func TestChaosFaults_ErrorRate(t *testing.T) {
+	roll := 0.7
+	s := newTestServer(t, withChaosRand(func() float64 { return roll }))
+	var records []logRecord
+	defer s.log.addSink(func(rec logRecord) { records = append(records, rec) })()
+	h := s.chaosFaults("/visits", okHandler)
+
+	withChaos(s, ChaosRule{Route: "/visits", ErrorRate: 0.5, Status: http.StatusServiceUnavailable})
+	if w := serveGet(h, "/visits", nil); w.Code != http.StatusOK {
+		t.Errorf("roll above the rate: status = %d, want 200", w.Code)
+	}
+
+	before := chaosFaultsTotal.get("error")
+	roll = 0.3
+	w := serveGet(h, "/visits", nil)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("roll below the rate: status = %d, want 503", w.Code)
+	}
+	if got := w.Header().Get("X-Chaos-Injected"); got != "status=503" {
+		t.Errorf("X-Chaos-Injected = %q", got)
+	}
+	if got := chaosFaultsTotal.get("error"); got != before+1 {
+		t.Errorf("devops_chaos_faults_total{fault=error} = %d, want %d", got, before+1)
+	}
+	if len(records) != 1 || records[0].Level != "warn" || records[0].Fields["chaos"] != "error" {
+		t.Errorf("records = %+v, want one warn marked chaos=error", records)
+	}
+}
+
+func TestChaosFaults_Latency(t *testing.T) {
+	roll := 1.0
+	s := newTestServer(t, withChaosRand(func() float64 { return roll }))
+	withChaos(s, ChaosRule{Route: "*", Latency: "30ms"}, ChaosRule{Route: "/health", ErrorRate: 1})
+
+	start := time.Now()
+	w := serveGet(s.chaosFaults("/visits", okHandler), "/visits", nil)
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond || w.Code != http.StatusOK {
+		t.Errorf("wildcard rule: %s, status %d, want 30ms of latency and 200", elapsed, w.Code)
+	}
+
+	// The exact rule wins over *: an error, without the wildcard's latency.
+	roll = 0
+	start = time.Now()
+	w = serveGet(s.chaosFaults("/health", okHandler), "/health", nil)
+	if elapsed := time.Since(start); elapsed >= 30*time.Millisecond || w.Code != http.StatusInternalServerError {
+		t.Errorf("exact rule: %s, status %d, want no latency and 500", elapsed, w.Code)
+	}
+}
+
+func TestChaosFaults_Headers(t *testing.T) {
+	s := newTestServer(t)
+	h := s.chaosFaults("/", okHandler)
+
+	if w := serveGet(h, "/", map[string]string{"X-Chaos-Status": "503"}); w.Code != http.StatusOK {
+		t.Errorf("chaos off: status = %d, headers must be ignored", w.Code)
+	}
+
+	withChaos(s)
+	if w := serveGet(h, "/", map[string]string{"X-Chaos-Status": "418"}); w.Code != http.StatusTeapot {
+		t.Errorf("X-Chaos-Status: status = %d, want 418", w.Code)
+	}
+	w := serveGet(h, "/", map[string]string{"X-Chaos-Latency": "20ms"})
+	if w.Code != http.StatusOK || w.Header().Get("X-Chaos-Injected") != "latency=20ms" {
+		t.Errorf("X-Chaos-Latency: status %d, X-Chaos-Injected %q", w.Code, w.Header().Get("X-Chaos-Injected"))
+	}
+	for _, header := range []map[string]string{{"X-Chaos-Status": "200"}, {"X-Chaos-Latency": "1h"}} {
+		if w := serveGet(h, "/", header); w.Code != http.StatusBadRequest {
+			t.Errorf("%v: status = %d, want 400", header, w.Code)
+		}
+	}
+}
+
+func TestChaos_AdminRoutesUnaffected(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AdminToken = "s3cret"
+	s := newTestServer(t, WithConfig(cfg))
+	withChaos(s, ChaosRule{Route: "*", ErrorRate: 1})
+	h := s.Handler()
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/health", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("/health: status = %d, want the injected 500", w.Code)
+	}
+
+	req := httptest.NewRequest("GET", "/admin/chaos", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("/admin/chaos: status = %d, want 200", w.Code)
+	}
+}
+
+func TestChaosHandler(t *testing.T) {
+	s := newTestServer(t)
+	put := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.chaosHandler(w, httptest.NewRequest(http.MethodPut, "/admin/chaos", strings.NewReader(body)))
+		return w
+	}
+
+	var resp ChaosResp
+	json.NewDecoder(serveGet(s.chaosHandler, "/admin/chaos", nil).Body).Decode(&resp)
+	if resp.Enabled || resp.Rules == nil {
+		t.Errorf("disabled GET = %+v", resp)
+	}
+	if w := put(`{"rules": []}`); w.Code != http.StatusConflict {
+		t.Errorf("disabled PUT: status = %d, want 409", w.Code)
+	}
+
+	state := withChaos(s)
+	w := put(`{"rules": [{"route": "/health", "error_rate": 1, "status": 503}]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT: status = %d, body %s", w.Code, w.Body)
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if !resp.Enabled || len(resp.Rules) != 1 || len(state.Rules()) != 1 {
+		t.Errorf("PUT = %+v, state %+v", resp, state.Rules())
+	}
+
+	if w := put(`{"rules": [{"route": "/", "error_rate": 3}]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid rule: status = %d, want 400", w.Code)
+	}
+	if len(state.Rules()) != 1 {
+		t.Error("a rejected PUT changed the rules")
+	}
+	if w := put(`{}`); w.Code != http.StatusOK || len(state.Rules()) != 0 {
+		t.Errorf("empty PUT: status %d, rules %+v, want all rules cleared", w.Code, state.Rules())
+	}
+}
@@ -0,0 +1,397 @@
+package infoservice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// ==================== BUILD INFO ====================
+
+// Set at build time:
+//
+//	go build -ldflags "-X $PKG.version=1.2.0 -X $PKG.commit=$(git rev-parse --short HEAD) -X $PKG.buildDate=$(date -u +%FT%TZ)" .
+//
+// with PKG=devops-info-service/pkg/infoservice.
+var (
+	version   = "1.0.0"
+	commit    = "unknown"
+	buildDate = "unknown"
+)
+
+// ==================== CLI ====================
+
+// Exit codes shared by every subcommand.
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+const usageText = `Usage: devops-info-service [command] [flags]
+
+Commands:
+  serve            start the HTTP server (default when no command is given)
+  version          print build information
+  config print     print the effective configuration (secrets redacted)
+  config validate  check the configuration and exit
+  probe URL [URL]  query / and /health of a running instance, or diff two instances
+  healthcheck      exit 0 if the local instance is healthy, 1 otherwise
+  help             show this help
+
+Configuration comes from defaults, then environment variables, then flags.
+Run "devops-info-service <command> --help" for the flags of a command.
+
+Exit codes: 0 success, 1 failure, 2 usage error.
+`
+
+// RunCLI dispatches args (without the program name) to a subcommand and
+// returns the process exit code. lookupEnv reads the environment.
+func RunCLI(args []string, lookupEnv func(string) (string, bool), stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		return runServe(nil, lookupEnv, stderr)
+	}
+
+	cmd, rest := args[0], args[1:]
+	switch cmd {
+	case "serve":
+		return runServe(rest, lookupEnv, stderr)
+	case "version":
+		return runVersion(rest, stdout, stderr)
+	case "config":
+		return runConfig(rest, lookupEnv, stdout, stderr)
+	case "probe":
+		return runProbe(rest, stdout, stderr)
+	case "healthcheck":
+		return runHealthcheck(rest, lookupEnv, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usageText)
+		return exitOK
+	}
+
+	if strings.HasPrefix(cmd, "-") {
+		// Flags without a command: `devops-info-service --port 9000`.
+		return runServe(args, lookupEnv, stderr)
+	}
+	fmt.Fprintf(stderr, "unknown command %q\n\n%s", cmd, usageText)
+	return exitUsage
+}
+
+// parseFlags parses args and maps the result to an exit code: -1 when the
+// command should go on, exitOK for --help and exitUsage for bad flags.
+func parseFlags(fs *flag.FlagSet, args []string) int {
+	err := fs.Parse(args)
+	switch {
+	case err == nil:
+		return -1
+	case errors.Is(err, flag.ErrHelp):
+		return exitOK
+	default:
+		return exitUsage
+	}
+}
+
+// loadCLIConfig merges defaults, environment and the config flags on fs.
+func loadCLIConfig(fs *flag.FlagSet, args []string, lookupEnv func(string) (string, bool), stderr io.Writer) (Config, int) {
+	cfg, err := configFromEnv(lookupEnv)
+	if err != nil {
+		fmt.Fprintf(stderr, "config: %v\n", err)
+		return cfg, exitFailure
+	}
+	bindConfigFlags(fs, &cfg)
+	if code := parseFlags(fs, args); code >= 0 {
+		return cfg, code
+	}
+	if err := cfg.validate(); err != nil {
+		fmt.Fprintf(stderr, "config: %v\n", err)
+		return cfg, exitFailure
+	}
+	return cfg, -1
+}
+
+func runServe(args []string, lookupEnv func(string) (string, bool), stderr io.Writer) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	cfg, code := loadCLIConfig(fs, args, lookupEnv, stderr)
+	if code >= 0 {
+		return code
+	}
+
+	if err := run(cfg); err != nil {
+		newLogger(nil, nil).errorf("server", "Server failed to start: %v", err)
+		return exitFailure
+	}
+	return exitOK
+}
+
+// ==================== VERSION ====================
+
+func runVersion(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("version", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	asJSON := fs.Bool("json", false, "print as JSON")
+	if code := parseFlags(fs, args); code >= 0 {
+		return code
+	}
+
+	info := buildInfo()
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(info)
+		return exitOK
+	}
+
+	fmt.Fprintf(stdout, "devops-info-service %s\n", info["version"])
+	tw := tabwriter.NewWriter(stdout, 0, 0, 1, ' ', 0)
+	for _, key := range []string{"commit", "build_date", "go_version", "platform"} {
+		fmt.Fprintf(tw, "  %s:\t%s\n", key, info[key])
+	}
+	tw.Flush()
+	return exitOK
+}
+
+// buildInfo reports the ldflags values, falling back to the VCS revision
+// the Go toolchain embeds when commit was not set explicitly.
+func buildInfo() map[string]string {
+	rev := commit
+	if rev == "unknown" {
+		if bi, ok := debug.ReadBuildInfo(); ok {
+			for _, s := range bi.Settings {
+				if s.Key == "vcs.revision" {
+					rev = s.Value
+				}
+			}
+		}
+	}
+	return map[string]string{
+		"version":    version,
+		"commit":     rev,
+		"build_date": buildDate,
+		"go_version": runtime.Version(),
+		"platform":   runtime.GOOS + "/" + runtime.GOARCH,
+	}
+}
+
+// ==================== CONFIG ====================
+
+func runConfig(args []string, lookupEnv func(string) (string, bool), stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, "usage: devops-info-service config print|validate [flags]\n")
+		return exitUsage
+	}
+
+	switch args[0] {
+	case "print":
+		fs := flag.NewFlagSet("config print", flag.ContinueOnError)
+		fs.SetOutput(stderr)
+		asJSON := fs.Bool("json", false, "print as JSON")
+		cfg, code := loadCLIConfig(fs, args[1:], lookupEnv, stderr)
+		if code >= 0 {
+			return code
+		}
+		printConfig(stdout, cfg, *asJSON)
+		return exitOK
+
+	case "validate":
+		fs := flag.NewFlagSet("config validate", flag.ContinueOnError)
+		fs.SetOutput(stderr)
+		if _, code := loadCLIConfig(fs, args[1:], lookupEnv, stderr); code >= 0 {
+			return code
+		}
+		fmt.Fprintln(stdout, "configuration is valid")
+		return exitOK
+	}
+
+	fmt.Fprintf(stderr, "unknown config command %q, want print or validate\n", args[0])
+	return exitUsage
+}
+
+func printConfig(w io.Writer, cfg Config, asJSON bool) {
+	entries := configEntries(cfg)
+	if asJSON {
+		out := make(map[string]string, len(entries))
+		for _, e := range entries {
+			out[e.Env] = e.Value
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(out)
+		return
+	}
+
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s=%s\n", e.Env, e.Value)
+	}
+}
+
+// ==================== PROBE ====================
+
+// Fields that always differ between two instances and only add noise to
+// `probe --diff` output.
+const defaultProbeIgnore = "runtime.current_time,runtime.uptime_seconds,runtime.uptime_human," +
+	"timestamp,uptime_seconds,request.client_ip"
+
+var probePaths = []string{"/", "/health"}
+
+func runProbe(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("probe", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, "usage: devops-info-service probe [flags] URL [OTHER_URL]\n\n"+
+			"With one URL, pretty-prints its / and /health responses.\n"+
+			"With two URLs, prints the fields that differ between them.\n\n")
+		fs.PrintDefaults()
+	}
+	timeout := fs.Duration("timeout", 5*time.Second, "timeout per request")
+	ignore := fs.String("ignore", defaultProbeIgnore, "comma-separated fields skipped when diffing")
+	if code := parseFlags(fs, args); code >= 0 {
+		return code
+	}
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	urls := fs.Args()
+	results := make([]map[string]interface{}, len(urls))
+	for i, base := range urls {
+		res, err := fetchEndpoints(strings.TrimRight(base, "/"), *timeout)
+		if err != nil {
+			fmt.Fprintf(stderr, "probe: %v\n", err)
+			return exitFailure
+		}
+		results[i] = res
+	}
+
+	if len(results) == 1 {
+		for _, path := range probePaths {
+			fmt.Fprintf(stdout, "GET %s%s\n", urls[0], path)
+			body, _ := json.MarshalIndent(results[0][path], "", "  ")
+			fmt.Fprintf(stdout, "%s\n\n", body)
+		}
+		return exitOK
+	}
+
+	skip := make(map[string]bool)
+	for _, f := range strings.Split(*ignore, ",") {
+		skip[strings.TrimSpace(f)] = true
+	}
+	for _, path := range probePaths {
+		fmt.Fprintf(stdout, "GET %s\n", path)
+		diffs := diffJSON(results[0][path], results[1][path], skip)
+		if len(diffs) == 0 {
+			fmt.Fprintln(stdout, "  (no differences)")
+		}
+		for _, d := range diffs {
+			fmt.Fprintf(stdout, "  %s\n", d)
+		}
+	}
+	return exitOK
+}
+
+// fetchEndpoints GETs every probe path under base and decodes the JSON bodies.
+func fetchEndpoints(base string, timeout time.Duration) (map[string]interface{}, error) {
+	out := make(map[string]interface{}, len(probePaths))
+	for _, path := range probePaths {
+		body, err := fetchJSON(base+path, timeout)
+		if err != nil {
+			return nil, err
+		}
+		out[path] = body
+	}
+	return out, nil
+}
+
+func fetchJSON(url string, timeout time.Duration) (interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	var body interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("%s: invalid JSON: %w", url, err)
+	}
+	return body, nil
+}
+
+// diffJSON flattens both documents to dotted paths and lists the paths
+// whose values differ, sorted, in "path: a -> b" form.
+func diffJSON(a, b interface{}, skip map[string]bool) []string {
+	fa, fb := map[string]string{}, map[string]string{}
+	flattenJSON("", a, fa)
+	flattenJSON("", b, fb)
+
+	keys := make(map[string]bool)
+	for k := range fa {
+		keys[k] = true
+	}
+	for k := range fb {
+		keys[k] = true
+	}
+
+	var diffs []string
+	for k := range keys {
+		if skip[k] {
+			continue
+		}
+		va, oka := fa[k]
+		vb, okb := fb[k]
+		switch {
+		case !oka:
+			diffs = append(diffs, fmt.Sprintf("%s: (missing) -> %s", k, vb))
+		case !okb:
+			diffs = append(diffs, fmt.Sprintf("%s: %s -> (missing)", k, va))
+		case va != vb:
+			diffs = append(diffs, fmt.Sprintf("%s: %s -> %s", k, va, vb))
+		}
+	}
+	sort.Strings(diffs)
+	return diffs
+}
+
+func flattenJSON(prefix string, v interface{}, out map[string]string) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			flattenJSON(join(k), child, out)
+		}
+	case []interface{}:
+		for i, child := range val {
+			flattenJSON(join(fmt.Sprint(i)), child, out)
+		}
+	default:
+		b, _ := json.Marshal(val)
+		out[prefix] = string(b)
+	}
+}
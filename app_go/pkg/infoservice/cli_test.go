@@ -0,0 +1,236 @@
+package infoservice
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// runCLIWithEnv runs the CLI with the environment env and returns the
+// exit code and captured output.
+func runCLIWithEnv(t *testing.T, env map[string]string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := RunCLI(args, envMap(env), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestCLI_Help(t *testing.T) {
+	for _, arg := range []string{"help", "--help", "-h"} {
+		code, stdout, _ := runCLIWithEnv(t, nil, arg)
+		if code != exitOK {
+			t.Errorf("%s: exit code = %d, want 0", arg, code)
+		}
+		for _, cmd := range []string{"serve", "version", "config print", "probe", "healthcheck"} {
+			if !strings.Contains(stdout, cmd) {
+				t.Errorf("%s: usage does not mention %q", arg, cmd)
+			}
+		}
+	}
+}
+
+func TestCLI_SubcommandHelp(t *testing.T) {
+	code, _, stderr := runCLIWithEnv(t, nil, "serve", "--help")
+	if code != exitOK {
+		t.Errorf("exit code = %d, want 0", code)
+	}
+	if !strings.Contains(stderr, "-read-header-timeout") {
+		t.Errorf("serve --help does not list config flags: %s", stderr)
+	}
+}
+
+func TestCLI_UnknownCommand(t *testing.T) {
+	code, _, stderr := runCLIWithEnv(t, nil, "deploy")
+	if code != exitUsage {
+		t.Errorf("exit code = %d, want %d", code, exitUsage)
+	}
+	if !strings.Contains(stderr, `unknown command "deploy"`) {
+		t.Errorf("unexpected stderr: %s", stderr)
+	}
+}
+
+func TestCLI_Version(t *testing.T) {
+	code, stdout, _ := runCLIWithEnv(t, nil, "version")
+	if code != exitOK {
+		t.Fatalf("exit code = %d, want 0", code)
+	}
+	if !strings.HasPrefix(stdout, "devops-info-service "+version) {
+		t.Errorf("unexpected version output: %s", stdout)
+	}
+	for _, key := range []string{"commit:", "build_date:", "go_version:", "platform:"} {
+		if !strings.Contains(stdout, key) {
+			t.Errorf("version output missing %s", key)
+		}
+	}
+
+	code, stdout, _ = runCLIWithEnv(t, nil, "version", "--json")
+	if code != exitOK {
+		t.Fatalf("--json exit code = %d, want 0", code)
+	}
+	var info map[string]string
+	if err := json.Unmarshal([]byte(stdout), &info); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if info["version"] != version {
+		t.Errorf("version = %s, want %s", info["version"], version)
+	}
+}
+
+func TestCLI_ConfigPrint_MergesEnvAndFlags(t *testing.T) {
+	env := map[string]string{"PORT": "9000", "READ_TIMEOUT": "3s"}
+	code, stdout, stderr := runCLIWithEnv(t, env, "config", "print", "--read-timeout", "7s")
+	if code != exitOK {
+		t.Fatalf("exit code = %d, want 0 (stderr: %s)", code, stderr)
+	}
+
+	for _, line := range []string{"HOST=0.0.0.0", "PORT=9000", "READ_TIMEOUT=7s", "MAX_CONNS=512"} {
+		if !strings.Contains(stdout, line+"\n") {
+			t.Errorf("config print missing %q:\n%s", line, stdout)
+		}
+	}
+}
+
+func TestCLI_ConfigPrint_JSON(t *testing.T) {
+	code, stdout, _ := runCLIWithEnv(t, map[string]string{"HOST": "127.0.0.1"}, "config", "print", "--json")
+	if code != exitOK {
+		t.Fatalf("exit code = %d, want 0", code)
+	}
+	var cfg map[string]string
+	if err := json.Unmarshal([]byte(stdout), &cfg); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if cfg["HOST"] != "127.0.0.1" {
+		t.Errorf("HOST = %s, want 127.0.0.1", cfg["HOST"])
+	}
+}
+
+func TestCLI_ConfigValidate(t *testing.T) {
+	testCases := []struct {
+		name string
+		env  map[string]string
+		args []string
+		want int
+	}{
+		{"valid", nil, nil, exitOK},
+		{"invalid env", map[string]string{"IDLE_TIMEOUT": "later"}, nil, exitFailure},
+		{"invalid flag value", nil, []string{"--port", "99999"}, exitFailure},
+		{"unknown flag", nil, []string{"--nope"}, exitUsage},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			args := append([]string{"config", "validate"}, tc.args...)
+			code, _, stderr := runCLIWithEnv(t, tc.env, args...)
+			if code != tc.want {
+				t.Errorf("exit code = %d, want %d (stderr: %s)", code, tc.want, stderr)
+			}
+		})
+	}
+}
+
+func TestConfigEntries_RedactsSecrets(t *testing.T) {
+	type secretConfig struct {
+		User  string `env:"USER_NAME"`
+		Token string `env:"API_TOKEN" secret:"true"`
+		Empty string `env:"EMPTY_TOKEN" secret:"true"`
+	}
+
+	entries := configEntries(secretConfig{User: "admin", Token: "s3cr3t"})
+	got := map[string]string{}
+	for _, e := range entries {
+		got[e.Env] = e.Value
+	}
+
+	if got["USER_NAME"] != "admin" {
+		t.Errorf("USER_NAME = %q, want admin", got["USER_NAME"])
+	}
+	if got["API_TOKEN"] != redacted {
+		t.Errorf("API_TOKEN = %q, want %s", got["API_TOKEN"], redacted)
+	}
+	if got["EMPTY_TOKEN"] != "" {
+		t.Errorf("empty secret should stay empty, got %q", got["EMPTY_TOKEN"])
+	}
+}
+
+func newProbeTarget(hostname string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"system":  map[string]string{"hostname": hostname},
+				"runtime": map[string]string{"current_time": time.Now().String()},
+			})
+		case "/health":
+			json.NewEncoder(w).Encode(map[string]string{"status": "healthy"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestCLI_Probe_Single(t *testing.T) {
+	server := newProbeTarget("pod-a")
+	defer server.Close()
+
+	code, stdout, stderr := runCLIWithEnv(t, nil, "probe", server.URL)
+	if code != exitOK {
+		t.Fatalf("exit code = %d, want 0 (stderr: %s)", code, stderr)
+	}
+	if !strings.Contains(stdout, "GET "+server.URL+"/health") || !strings.Contains(stdout, `"hostname": "pod-a"`) {
+		t.Errorf("unexpected probe output:\n%s", stdout)
+	}
+}
+
+func TestCLI_Probe_Diff(t *testing.T) {
+	a, b := newProbeTarget("pod-a"), newProbeTarget("pod-b")
+	defer a.Close()
+	defer b.Close()
+
+	code, stdout, stderr := runCLIWithEnv(t, nil, "probe", a.URL, b.URL)
+	if code != exitOK {
+		t.Fatalf("exit code = %d, want 0 (stderr: %s)", code, stderr)
+	}
+	if !strings.Contains(stdout, `system.hostname: "pod-a" -> "pod-b"`) {
+		t.Errorf("diff does not show hostname change:\n%s", stdout)
+	}
+	if strings.Contains(stdout, "current_time") {
+		t.Errorf("volatile field should be ignored:\n%s", stdout)
+	}
+	if !strings.Contains(stdout, "(no differences)") {
+		t.Errorf("expected /health to have no differences:\n%s", stdout)
+	}
+}
+
+func TestCLI_Probe_Errors(t *testing.T) {
+	code, _, _ := runCLIWithEnv(t, nil, "probe")
+	if code != exitUsage {
+		t.Errorf("no URL: exit code = %d, want %d", code, exitUsage)
+	}
+
+	code, _, stderr := runCLIWithEnv(t, nil, "probe", "--timeout", "1s", "http://127.0.0.1:1")
+	if code != exitFailure {
+		t.Errorf("unreachable: exit code = %d, want %d", code, exitFailure)
+	}
+	if !strings.Contains(stderr, "probe:") {
+		t.Errorf("expected error on stderr, got %q", stderr)
+	}
+}
+
+func TestDiffJSON(t *testing.T) {
+	a := map[string]interface{}{"x": 1.0, "nested": map[string]interface{}{"y": "a"}, "only_a": true}
+	b := map[string]interface{}{"x": 1.0, "nested": map[string]interface{}{"y": "b"}, "only_b": false}
+
+	got := diffJSON(a, b, nil)
+	want := []string{
+		`nested.y: "a" -> "b"`,
+		`only_a: true -> (missing)`,
+		`only_b: (missing) -> false`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("diffJSON =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,245 @@
+package infoservice
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ==================== COMPRESSION ====================
+
+// Encodings the middleware can produce, in server preference order. Brotli
+// and zstd are left out on purpose: the standard library has no encoder for
+// them and the service has no third-party dependencies.
+var supportedEncodings = []string{"gzip", "deflate"}
+
+// Content types that are already compressed; compressing them again only
+// burns CPU.
+var incompressibleTypes = []string{
+	"image/", "video/", "audio/", "font/woff",
+	"application/zip", "application/gzip", "application/x-gzip",
+	"application/zstd", "application/x-bzip2", "application/x-xz",
+	"application/octet-stream",
+	"text/event-stream", // compressed streams stall behind proxies that buffer
+}
+
+// compressor holds the writer pools for one compression level. Pooling the
+// writers matters: a gzip.Writer allocates several hundred KB on creation.
+type compressor struct {
+	minSize  int
+	gzipPool sync.Pool
+	// HTTP deflate is the zlib format (RFC 1950), not raw DEFLATE.
+	zlibPool sync.Pool
+}
+
+// compress negotiates Accept-Encoding and compresses response bodies of at
+// least minSize bytes with gzip or deflate at the given level.
+func compress(minSize, level int, next http.Handler) http.Handler {
+	c := &compressor{minSize: minSize}
+	c.gzipPool.New = func() interface{} {
+		w, _ := gzip.NewWriterLevel(io.Discard, level)
+		return w
+	}
+	c.zlibPool.New = func() interface{} {
+		w, _ := zlib.NewWriterLevel(io.Discard, level)
+		return w
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		// Upgrade requests (WebSocket) hijack the connection, so there is
+		// no response body to compress.
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Method == http.MethodHead || r.Header.Get("Upgrade") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, c: c, encoding: encoding, status: http.StatusOK}
+		defer cw.Close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// negotiateEncoding returns the supported encoding with the highest q-value
+// in header, or "" when the client accepts none of them.
+func negotiateEncoding(header string) string {
+	if header == "" {
+		return ""
+	}
+
+	qs := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if parsed, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		qs[name] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, enc := range supportedEncodings {
+		q, ok := qs[enc]
+		if !ok {
+			q, ok = qs["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+	return best
+}
+
+func isCompressible(contentType string) bool {
+	ct := strings.ToLower(contentType)
+	if strings.HasPrefix(ct, "image/svg+xml") {
+		return true
+	}
+	for _, prefix := range incompressibleTypes {
+		if strings.HasPrefix(ct, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// compressWriter buffers the start of the body until it knows whether the
+// response is worth compressing: at least minSize bytes, a compressible
+// content type and no Content-Encoding set by the handler.
+type compressWriter struct {
+	http.ResponseWriter
+	c        *compressor
+	encoding string
+
+	status  int
+	buf     []byte
+	decided bool
+	enc     io.WriteCloser
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.decided || status < 200 {
+		// Informational responses go straight through.
+		cw.ResponseWriter.WriteHeader(status)
+		return
+	}
+	cw.status = status
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if !cw.decided {
+		cw.buf = append(cw.buf, p...)
+		if len(cw.buf) < cw.c.minSize {
+			return len(p), nil
+		}
+		if err := cw.decide(); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if cw.enc != nil {
+		return cw.enc.Write(p)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// decide sends the headers, picks compressed or plain output and writes the
+// buffered bytes through it.
+func (cw *compressWriter) decide() error {
+	cw.decided = true
+	h := cw.Header()
+	if h.Get("Content-Type") == "" && len(cw.buf) > 0 {
+		h.Set("Content-Type", http.DetectContentType(cw.buf))
+	}
+
+	if len(cw.buf) >= cw.c.minSize && len(cw.buf) > 0 &&
+		h.Get("Content-Encoding") == "" &&
+		cw.status != http.StatusNoContent && cw.status != http.StatusNotModified &&
+		isCompressible(h.Get("Content-Type")) {
+		h.Set("Content-Encoding", cw.encoding)
+		h.Del("Content-Length")
+		cw.enc = cw.c.getWriter(cw.encoding, cw.ResponseWriter)
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if cw.enc != nil {
+		_, err := cw.enc.Write(buf)
+		return err
+	}
+	_, err := cw.ResponseWriter.Write(buf)
+	return err
+}
+
+// Flush decides early (a streamed response smaller than minSize stays
+// uncompressed) and pushes any compressed bytes to the client.
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		cw.decide()
+	}
+	if f, ok := cw.enc.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	// Through ResponseController, as the writer below may be another
+	// wrapper such as the access log's.
+	http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+// Close finishes the response and returns the encoder to its pool.
+func (cw *compressWriter) Close() error {
+	if !cw.decided {
+		if err := cw.decide(); err != nil {
+			return err
+		}
+	}
+	if cw.enc == nil {
+		return nil
+	}
+	err := cw.enc.Close()
+	cw.c.putWriter(cw.encoding, cw.enc)
+	cw.enc = nil
+	return err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+func (c *compressor) getWriter(encoding string, w io.Writer) io.WriteCloser {
+	switch encoding {
+	case "gzip":
+		gz := c.gzipPool.Get().(*gzip.Writer)
+		gz.Reset(w)
+		return gz
+	default:
+		zw := c.zlibPool.Get().(*zlib.Writer)
+		zw.Reset(w)
+		return zw
+	}
+}
+
+func (c *compressor) putWriter(encoding string, w io.WriteCloser) {
+	switch encoding {
+	case "gzip":
+		c.gzipPool.Put(w)
+	default:
+		c.zlibPool.Put(w)
+	}
+}
@@ -0,0 +1,211 @@
+package infoservice
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	testCases := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", "gzip"},
+		{"deflate", "deflate"},
+		{"gzip, deflate, br", "gzip"},
+		{"br, deflate", "deflate"},
+		{"deflate;q=1.0, gzip;q=0.5", "deflate"},
+		{"gzip;q=0, deflate", "deflate"},
+		{"gzip;q=0, deflate;q=0", ""},
+		{"*", "gzip"},
+		{"*;q=0.5, gzip;q=0", "deflate"},
+		{"GZIP", "gzip"},
+		{"br, zstd", ""},
+	}
+
+	for _, tc := range testCases {
+		if got := negotiateEncoding(tc.header); got != tc.want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", tc.header, got, tc.want)
+		}
+	}
+}
+
+func serveCompressed(h http.Handler, acceptEncoding string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/", nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	w := httptest.NewRecorder()
+	compress(1024, -1, h).ServeHTTP(w, req)
+	return w
+}
+
+func decodeBody(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var r io.Reader = w.Body
+	switch w.Header().Get("Content-Encoding") {
+	case "gzip":
+		gz, err := gzip.NewReader(w.Body)
+		if err != nil {
+			t.Fatalf("gzip reader: %v", err)
+		}
+		r = gz
+	case "deflate":
+		zr, err := zlib.NewReader(w.Body)
+		if err != nil {
+			t.Fatalf("zlib reader: %v", err)
+		}
+		r = zr
+	}
+	body, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	return string(body)
+}
+
+func TestCompress_LargeJSON(t *testing.T) {
+	big := strings.Repeat(`{"key": "value"},`, 200)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, big[:100])
+		io.WriteString(w, big[100:])
+	})
+
+	for _, enc := range []string{"gzip", "deflate"} {
+		t.Run(enc, func(t *testing.T) {
+			w := serveCompressed(handler, enc)
+			if w.Code != http.StatusCreated {
+				t.Errorf("status = %d, want 201", w.Code)
+			}
+			if got := w.Header().Get("Content-Encoding"); got != enc {
+				t.Fatalf("Content-Encoding = %q, want %s", got, enc)
+			}
+			if w.Body.Len() >= len(big) {
+				t.Errorf("compressed body (%d bytes) not smaller than original (%d)", w.Body.Len(), len(big))
+			}
+			if body := decodeBody(t, w); body != big {
+				t.Error("decompressed body does not match original")
+			}
+		})
+	}
+}
+
+func TestCompress_Skips(t *testing.T) {
+	big := strings.Repeat("x", 4096)
+	testCases := []struct {
+		name           string
+		acceptEncoding string
+		handler        http.HandlerFunc
+	}{
+		{"small body", "gzip", func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, `{"status":"healthy"}`)
+		}},
+		{"no accept-encoding", "", func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, big)
+		}},
+		{"already compressed type", "gzip", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "image/png")
+			io.WriteString(w, big)
+		}},
+		{"handler set encoding", "gzip", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Encoding", "br")
+			io.WriteString(w, big)
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := serveCompressed(tc.handler, tc.acceptEncoding)
+			if got := w.Header().Get("Content-Encoding"); got != "" && got != "br" {
+				t.Errorf("Content-Encoding = %q, want no compression", got)
+			}
+			if !strings.Contains(w.Header().Get("Vary"), "Accept-Encoding") {
+				t.Error("Vary must include Accept-Encoding even when not compressing")
+			}
+		})
+	}
+}
+
+func TestCompress_NoBody(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	w := serveCompressed(handler, "gzip")
+	if w.Code != http.StatusNoContent {
+		t.Errorf("status = %d, want 204", w.Code)
+	}
+	if w.Header().Get("Content-Encoding") != "" || w.Body.Len() != 0 {
+		t.Error("empty response must not be compressed")
+	}
+}
+
+func TestCompress_InfoEndpoint(t *testing.T) {
+	server := httptest.NewServer(newTestServer(t).Handler())
+	defer server.Close()
+
+	// The Go client adds Accept-Encoding: gzip and decompresses transparently.
+	resp, err := http.Get(server.URL + "/")
+	if err != nil {
+		t.Fatalf("GET /: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if !resp.Uncompressed {
+		t.Error("info response was not gzip-compressed")
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), `"devops-info-service"`) {
+		t.Errorf("unexpected body: %s", body)
+	}
+}
+
+func TestCompress_WriterReuse(t *testing.T) {
+	big := strings.Repeat("reuse me ", 500)
+	h := compress(0, -1, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, big)
+	}))
+
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if body := decodeBody(t, w); body != big {
+			t.Fatalf("response %d corrupted after writer reuse", i)
+		}
+	}
+}
+
+func BenchmarkMainHandler_Gzip(b *testing.B) {
+	h := compress(512, -1, http.HandlerFunc(newTestServer(b).mainHandler))
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+	}
+}
+
+func BenchmarkMainHandler_Uncompressed(b *testing.B) {
+	srv := newTestServer(b)
+	req := httptest.NewRequest("GET", "/", nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w := httptest.NewRecorder()
+		srv.mainHandler(w, req)
+	}
+}
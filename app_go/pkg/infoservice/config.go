@@ -0,0 +1,456 @@
+package infoservice
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ==================== КОНФИГУРАЦИЯ ====================
+
+// Config holds every tunable setting of the service. Each field is filled
+// from the environment variable named in its env tag; variables that are
+// unset keep the value from DefaultConfig.
+type Config struct {
+	Host string `env:"HOST" help:"server bind address"`
+	Port string `env:"PORT" help:"server port number"`
+
+	DeployColor    string `env:"DEPLOY_COLOR" help:"blue/green stack this instance belongs to, e.g. blue or green"`
+	DeployTrack    string `env:"DEPLOY_TRACK" help:"rollout track, e.g. stable or canary"`
+	DeployRevision string `env:"DEPLOY_REVISION" help:"rollout revision, e.g. the pod template hash"`
+
+	DataDir string `env:"DATA_DIR" help:"directory for persistent data such as the visit counter"`
+
+	Timezone string `env:"TIMEZONE" help:"IANA timezone of runtime.current_time in GET /, e.g. Europe/Moscow"`
+
+	AdminToken    string `env:"ADMIN_TOKEN" secret:"true" help:"bearer token for the /admin API, empty disables it"`
+	LogBufferSize int    `env:"LOG_BUFFER_SIZE" help:"log records kept in memory for /admin/logs"`
+
+	LogLevel           string        `env:"LOG_LEVEL" help:"minimum log level: debug, info, warn or error"`
+	LogComponentLevels string        `env:"LOG_COMPONENT_LEVELS" help:"per-component log levels, e.g. http=warn,storage=debug"`
+	LogDebugTimeout    time.Duration `env:"LOG_DEBUG_TIMEOUT" help:"how long debug logging stays on after SIGUSR1"`
+
+	AccessLogFormat       string  `env:"ACCESS_LOG_FORMAT" help:"access log format: common, combined, json or off"`
+	AccessLogExclude      string  `env:"ACCESS_LOG_EXCLUDE" help:"comma-separated paths left out of the access log, a trailing * matches a prefix"`
+	AccessLogHealthSample float64 `env:"ACCESS_LOG_HEALTH_SAMPLE" help:"share of successful /health and /ready requests that are logged, 0 to 1"`
+	AccessLogFile         string  `env:"ACCESS_LOG_FILE" help:"write the access log to this file instead of the service log"`
+	AccessLogMaxSize      int64   `env:"ACCESS_LOG_MAX_SIZE" help:"size in bytes at which ACCESS_LOG_FILE is rotated"`
+	AccessLogMaxBackups   int     `env:"ACCESS_LOG_MAX_BACKUPS" help:"rotated access log files kept, 0 keeps none"`
+
+	ChaosEnabled bool   `env:"CHAOS_ENABLED" help:"allow fault injection through CHAOS_RULES, /admin/chaos and X-Chaos-* headers"`
+	ChaosRules   string `env:"CHAOS_RULES" help:"initial chaos rules as a JSON array, e.g. [{\"route\":\"/\",\"error_rate\":0.5}]"`
+
+	Maintenance             bool          `env:"MAINTENANCE" help:"start in maintenance mode, e.g. from a ConfigMap"`
+	MaintenanceMessage      string        `env:"MAINTENANCE_MESSAGE" help:"message shown while MAINTENANCE is on"`
+	MaintenanceUntil        string        `env:"MAINTENANCE_UNTIL" help:"expected end of maintenance as an RFC 3339 time, shown to clients"`
+	MaintenanceFile         string        `env:"MAINTENANCE_FILE,allowempty" help:"sentinel file that turns maintenance mode on while it exists, relative to DATA_DIR; empty disables"`
+	MaintenancePollInterval time.Duration `env:"MAINTENANCE_POLL_INTERVAL" help:"how often MAINTENANCE_FILE is checked"`
+
+	ShedEnabled       bool          `env:"SHED_ENABLED" help:"limit concurrent requests and reject the excess with 503 when latency rises"`
+	ShedTargetLatency time.Duration `env:"SHED_TARGET_LATENCY" help:"latency above which load shedding lowers its concurrency limit"`
+	ShedMinLimit      int           `env:"SHED_MIN_LIMIT" help:"lowest concurrency limit load shedding backs off to"`
+	ShedMaxLimit      int           `env:"SHED_MAX_LIMIT" help:"highest concurrency limit, also the starting one"`
+	ShedQueueSize     int           `env:"SHED_QUEUE_SIZE" help:"requests that may wait for a slot before the rest are rejected"`
+	ShedQueueTimeout  time.Duration `env:"SHED_QUEUE_TIMEOUT" help:"how long a request waits for a slot before it is rejected"`
+
+	NTPServer   string        `env:"NTP_SERVER" help:"SNTP server the clock is measured against, e.g. pool.ntp.org; empty disables"`
+	NTPInterval time.Duration `env:"NTP_INTERVAL" help:"how often the clock is measured against NTP_SERVER"`
+	NTPTimeout  time.Duration `env:"NTP_TIMEOUT" help:"how long to wait for NTP_SERVER to answer"`
+	NTPMaxSkew  time.Duration `env:"NTP_MAX_SKEW" help:"clock offset above which /health reports degraded"`
+
+	ReadTimeout        time.Duration `env:"READ_TIMEOUT" help:"max time to read a whole request"`
+	ReadHeaderTimeout  time.Duration `env:"READ_HEADER_TIMEOUT" help:"max time to read request headers"`
+	WriteTimeout       time.Duration `env:"WRITE_TIMEOUT" help:"max time to write a response"`
+	IdleTimeout        time.Duration `env:"IDLE_TIMEOUT" help:"how long idle keep-alive connections stay open"`
+	MaxHeaderBytes     int           `env:"MAX_HEADER_BYTES" help:"max size of request headers in bytes"`
+	MaxBodyBytes       int64         `env:"MAX_BODY_BYTES" help:"max size of a request body in bytes"`
+	MaxConns           int           `env:"MAX_CONNS" help:"max simultaneously open connections, 0 means no limit"`
+	ShutdownTimeout    time.Duration `env:"SHUTDOWN_TIMEOUT" help:"how long graceful shutdown waits for open requests"`
+	ShutdownDrainDelay time.Duration `env:"SHUTDOWN_DRAIN_DELAY" help:"how long /ready fails before shutdown stops accepting connections, so load balancers stop routing first"`
+
+	Compression      bool `env:"COMPRESSION" help:"compress responses with gzip or deflate"`
+	CompressMinBytes int  `env:"COMPRESS_MIN_BYTES" help:"smallest response body that gets compressed"`
+	CompressLevel    int  `env:"COMPRESS_LEVEL" help:"compression level, -1 (default) or 1 (fastest) to 9 (best)"`
+
+	EventsInterval  time.Duration `env:"EVENTS_INTERVAL" help:"how often /events publishes runtime stats"`
+	EventsHeartbeat time.Duration `env:"EVENTS_HEARTBEAT" help:"interval between /events heartbeat comments, 0 disables"`
+	EventsBuffer    int           `env:"EVENTS_BUFFER" help:"events queued per /events client before it is dropped as too slow"`
+	EventsHistory   int           `env:"EVENTS_HISTORY" help:"events kept for Last-Event-ID resume"`
+
+	WSMaxConns       int           `env:"WS_MAX_CONNS" help:"max open /ws connections, 0 means no limit"`
+	WSAllowedOrigins string        `env:"WS_ALLOWED_ORIGINS" help:"comma-separated origins allowed on /ws besides the service's own, * allows any"`
+	WSPingInterval   time.Duration `env:"WS_PING_INTERVAL" help:"how often /ws pings clients; no answer within two intervals closes the connection"`
+}
+
+// DefaultConfig is the configuration used when nothing is set.
+func DefaultConfig() Config {
+	return Config{
+		Host: "0.0.0.0",
+		Port: "8000",
+
+		DeployTrack: "stable",
+
+		DataDir: "data",
+
+		Timezone: "UTC",
+
+		LogBufferSize: 1000,
+
+		LogLevel:        "info",
+		LogDebugTimeout: 15 * time.Minute,
+
+		AccessLogFormat:       "combined",
+		AccessLogHealthSample: 1,
+		AccessLogMaxSize:      10 << 20,
+		AccessLogMaxBackups:   5,
+
+		MaintenanceMessage:      defaultMaintenanceMessage,
+		MaintenanceFile:         "maintenance",
+		MaintenancePollInterval: 5 * time.Second,
+
+		ShedTargetLatency: 100 * time.Millisecond,
+		ShedMinLimit:      2,
+		ShedMaxLimit:      100,
+		ShedQueueSize:     50,
+		ShedQueueTimeout:  500 * time.Millisecond,
+
+		NTPInterval: 5 * time.Minute,
+		NTPTimeout:  5 * time.Second,
+		NTPMaxSkew:  time.Second,
+
+		ReadTimeout:        10 * time.Second,
+		ReadHeaderTimeout:  5 * time.Second,
+		WriteTimeout:       30 * time.Second,
+		IdleTimeout:        60 * time.Second,
+		MaxHeaderBytes:     64 << 10,
+		MaxBodyBytes:       1 << 20,
+		MaxConns:           512,
+		ShutdownTimeout:    15 * time.Second,
+		ShutdownDrainDelay: 5 * time.Second,
+
+		Compression:      true,
+		CompressMinBytes: 512,
+		CompressLevel:    -1,
+
+		EventsInterval:  5 * time.Second,
+		EventsHeartbeat: 15 * time.Second,
+		EventsBuffer:    16,
+		EventsHistory:   64,
+
+		WSMaxConns:     64,
+		WSPingInterval: 30 * time.Second,
+	}
+}
+
+// LoadConfig starts from DefaultConfig and applies every environment
+// variable that is set, then validates the result. A set but empty
+// variable keeps the default, unless its env tag says allowempty: then it
+// clears the setting, so MAINTENANCE_FILE= turns the sentinel file off.
+func LoadConfig(lookupEnv func(string) (string, bool)) (Config, error) {
+	cfg, err := configFromEnv(lookupEnv)
+	if err != nil {
+		return cfg, err
+	}
+	if err := cfg.validate(); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// configFromEnv is LoadConfig without validation, for callers that apply
+// command-line flags on top before validating.
+func configFromEnv(lookupEnv func(string) (string, bool)) (Config, error) {
+	cfg := DefaultConfig()
+
+	v := reflect.ValueOf(&cfg).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, allowEmpty := envName(t.Field(i))
+		if name == "" {
+			continue
+		}
+		raw, ok := lookupEnv(name)
+		if !ok || raw == "" && !allowEmpty {
+			continue
+		}
+		if err := setField(v.Field(i), raw); err != nil {
+			return cfg, fmt.Errorf("invalid %s=%q: %w", name, raw, err)
+		}
+	}
+	return cfg, nil
+}
+
+// bindConfigFlags registers one flag per Config field on fs, named after the
+// env tag (READ_TIMEOUT becomes --read-timeout). The current values in cfg
+// become the flag defaults, so flags override the environment.
+func bindConfigFlags(fs *flag.FlagSet, cfg *Config) {
+	v := reflect.ValueOf(cfg).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if name, _ := envName(field); name == "" {
+			continue
+		}
+		fs.Var(fieldFlag{v.Field(i)}, flagName(field), field.Tag.Get("help"))
+	}
+}
+
+// envName reads the env tag of a config field: the variable name, and
+// whether the allowempty option lets a set but empty variable clear it.
+func envName(field reflect.StructField) (name string, allowEmpty bool) {
+	name, opts, _ := strings.Cut(field.Tag.Get("env"), ",")
+	return name, opts == "allowempty"
+}
+
+func flagName(field reflect.StructField) string {
+	name, _ := envName(field)
+	return strings.ReplaceAll(strings.ToLower(name), "_", "-")
+}
+
+type fieldFlag struct{ v reflect.Value }
+
+func (f fieldFlag) String() string {
+	if !f.v.IsValid() {
+		return ""
+	}
+	return formatField(f.v)
+}
+
+func (f fieldFlag) Set(raw string) error { return setField(f.v, raw) }
+
+// configEntry is one setting as shown by `config print`.
+type configEntry struct {
+	Env   string
+	Flag  string
+	Value string
+	Help  string
+}
+
+// configEntries lists the settings of a config struct in declaration order.
+// Fields tagged secret:"true" are redacted unless empty.
+func configEntries(cfg interface{}) []configEntry {
+	v := reflect.ValueOf(cfg)
+	t := v.Type()
+
+	var entries []configEntry
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _ := envName(field)
+		if name == "" {
+			continue
+		}
+		value := formatField(v.Field(i))
+		if field.Tag.Get("secret") == "true" && value != "" {
+			value = redacted
+		}
+		entries = append(entries, configEntry{
+			Env:   name,
+			Flag:  flagName(field),
+			Value: value,
+			Help:  field.Tag.Get("help"),
+		})
+	}
+	return entries
+}
+
+const redacted = "[REDACTED]"
+
+func formatField(f reflect.Value) string {
+	if d, ok := f.Interface().(time.Duration); ok {
+		return d.String()
+	}
+	return fmt.Sprint(f.Interface())
+}
+
+func setField(f reflect.Value, raw string) error {
+	switch f.Interface().(type) {
+	case time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		f.SetInt(int64(d))
+		return nil
+	}
+
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(raw)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		f.SetInt(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		f.SetBool(b)
+	case reflect.Float64:
+		x, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		f.SetFloat(x)
+	default:
+		return fmt.Errorf("unsupported field kind %s", f.Kind())
+	}
+	return nil
+}
+
+func (c Config) validate() error {
+	port, err := strconv.Atoi(c.Port)
+	if err != nil || port < 0 || port > 65535 {
+		return fmt.Errorf("invalid PORT %q: must be a number between 0 and 65535", c.Port)
+	}
+
+	for _, x := range []struct{ name, value string }{
+		{"DEPLOY_COLOR", c.DeployColor},
+		{"DEPLOY_TRACK", c.DeployTrack},
+		{"DEPLOY_REVISION", c.DeployRevision},
+	} {
+		if !validIdentity(x.value) {
+			return fmt.Errorf("invalid %s %q: use up to 63 letters, digits, '-', '_' or '.'", x.name, x.value)
+		}
+	}
+
+	durations := []struct {
+		name string
+		d    time.Duration
+	}{
+		{"READ_TIMEOUT", c.ReadTimeout},
+		{"READ_HEADER_TIMEOUT", c.ReadHeaderTimeout},
+		{"WRITE_TIMEOUT", c.WriteTimeout},
+		{"IDLE_TIMEOUT", c.IdleTimeout},
+		{"SHUTDOWN_TIMEOUT", c.ShutdownTimeout},
+		{"SHUTDOWN_DRAIN_DELAY", c.ShutdownDrainDelay},
+		{"EVENTS_HEARTBEAT", c.EventsHeartbeat},
+	}
+	for _, x := range durations {
+		if x.d < 0 {
+			return fmt.Errorf("invalid %s %s: must not be negative", x.name, x.d)
+		}
+	}
+
+	if c.MaxHeaderBytes < 0 {
+		return fmt.Errorf("invalid MAX_HEADER_BYTES %d: must not be negative", c.MaxHeaderBytes)
+	}
+	if c.MaxBodyBytes < 0 {
+		return fmt.Errorf("invalid MAX_BODY_BYTES %d: must not be negative", c.MaxBodyBytes)
+	}
+	if c.MaxConns < 0 {
+		return fmt.Errorf("invalid MAX_CONNS %d: must not be negative", c.MaxConns)
+	}
+	if c.CompressMinBytes < 0 {
+		return fmt.Errorf("invalid COMPRESS_MIN_BYTES %d: must not be negative", c.CompressMinBytes)
+	}
+	if c.CompressLevel < -1 || c.CompressLevel > 9 {
+		return fmt.Errorf("invalid COMPRESS_LEVEL %d: must be between -1 and 9", c.CompressLevel)
+	}
+	if c.LogBufferSize <= 0 {
+		return fmt.Errorf("invalid LOG_BUFFER_SIZE %d: must be positive", c.LogBufferSize)
+	}
+	if _, _, err := c.logLevels(); err != nil {
+		return err
+	}
+	if c.LogDebugTimeout <= 0 {
+		return fmt.Errorf("invalid LOG_DEBUG_TIMEOUT %s: must be positive", c.LogDebugTimeout)
+	}
+	if !containsString(accessLogFormats, c.AccessLogFormat) {
+		return fmt.Errorf("invalid ACCESS_LOG_FORMAT %q: must be one of %s", c.AccessLogFormat, strings.Join(accessLogFormats, ", "))
+	}
+	if c.AccessLogHealthSample < 0 || c.AccessLogHealthSample > 1 {
+		return fmt.Errorf("invalid ACCESS_LOG_HEALTH_SAMPLE %g: must be between 0 and 1", c.AccessLogHealthSample)
+	}
+	if c.AccessLogMaxSize <= 0 {
+		return fmt.Errorf("invalid ACCESS_LOG_MAX_SIZE %d: must be positive", c.AccessLogMaxSize)
+	}
+	if c.AccessLogMaxBackups < 0 {
+		return fmt.Errorf("invalid ACCESS_LOG_MAX_BACKUPS %d: must not be negative", c.AccessLogMaxBackups)
+	}
+	if _, err := parseChaosRules(c.ChaosRules); err != nil {
+		return fmt.Errorf("invalid CHAOS_RULES: %w", err)
+	}
+	if _, err := loadTimezone(c.Timezone); err != nil {
+		return fmt.Errorf("invalid TIMEZONE: %w", err)
+	}
+	if _, err := parseMaintenanceUntil(c.MaintenanceUntil); err != nil {
+		return fmt.Errorf("invalid MAINTENANCE_UNTIL: %w", err)
+	}
+	if c.MaintenancePollInterval <= 0 {
+		return fmt.Errorf("invalid MAINTENANCE_POLL_INTERVAL %s: must be positive", c.MaintenancePollInterval)
+	}
+	if c.ShedTargetLatency <= 0 {
+		return fmt.Errorf("invalid SHED_TARGET_LATENCY %s: must be positive", c.ShedTargetLatency)
+	}
+	if c.ShedMinLimit < 1 {
+		return fmt.Errorf("invalid SHED_MIN_LIMIT %d: must be at least 1", c.ShedMinLimit)
+	}
+	if c.ShedMaxLimit < c.ShedMinLimit {
+		return fmt.Errorf("invalid SHED_MAX_LIMIT %d: must be at least SHED_MIN_LIMIT (%d)", c.ShedMaxLimit, c.ShedMinLimit)
+	}
+	if c.ShedQueueSize < 0 {
+		return fmt.Errorf("invalid SHED_QUEUE_SIZE %d: must not be negative", c.ShedQueueSize)
+	}
+	if c.ShedQueueTimeout <= 0 {
+		return fmt.Errorf("invalid SHED_QUEUE_TIMEOUT %s: must be positive", c.ShedQueueTimeout)
+	}
+	if c.NTPServer != "" {
+		host, port, err := net.SplitHostPort(ntpAddress(c.NTPServer))
+		if n, _ := strconv.Atoi(port); err != nil || host == "" || n < 1 || n > 65535 {
+			return fmt.Errorf("invalid NTP_SERVER %q: want host or host:port", c.NTPServer)
+		}
+	}
+	if c.NTPInterval <= 0 {
+		return fmt.Errorf("invalid NTP_INTERVAL %s: must be positive", c.NTPInterval)
+	}
+	if c.NTPTimeout <= 0 {
+		return fmt.Errorf("invalid NTP_TIMEOUT %s: must be positive", c.NTPTimeout)
+	}
+	if c.NTPMaxSkew <= 0 {
+		return fmt.Errorf("invalid NTP_MAX_SKEW %s: must be positive", c.NTPMaxSkew)
+	}
+	if c.EventsInterval <= 0 {
+		return fmt.Errorf("invalid EVENTS_INTERVAL %s: must be positive", c.EventsInterval)
+	}
+	if c.EventsBuffer <= 0 {
+		return fmt.Errorf("invalid EVENTS_BUFFER %d: must be positive", c.EventsBuffer)
+	}
+	if c.EventsHistory < 0 {
+		return fmt.Errorf("invalid EVENTS_HISTORY %d: must not be negative", c.EventsHistory)
+	}
+	if c.WSMaxConns < 0 {
+		return fmt.Errorf("invalid WS_MAX_CONNS %d: must not be negative", c.WSMaxConns)
+	}
+	if c.WSPingInterval <= 0 {
+		return fmt.Errorf("invalid WS_PING_INTERVAL %s: must be positive", c.WSPingInterval)
+	}
+	return nil
+}
+
+func (c Config) deployment() Deployment {
+	return Deployment{Color: c.DeployColor, Track: c.DeployTrack, Revision: c.DeployRevision}
+}
+
+// logLevels parses LOG_LEVEL and LOG_COMPONENT_LEVELS.
+func (c Config) logLevels() (logLevel, map[string]logLevel, error) {
+	global, err := parseLogLevel(c.LogLevel)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid LOG_LEVEL: %w", err)
+	}
+	components, err := parseComponentLevels(c.LogComponentLevels)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid LOG_COMPONENT_LEVELS: %w", err)
+	}
+	return global, components, nil
+}
+
+func (c Config) addr() string {
+	return fmt.Sprintf("%s:%s", c.Host, c.Port)
+}
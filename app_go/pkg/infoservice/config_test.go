@@ -0,0 +1,140 @@
+package infoservice
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func envMap(m map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := m[key]
+		return v, ok
+	}
+}
+
+func TestLoadConfig_Defaults(t *testing.T) {
+	cfg, err := LoadConfig(envMap(nil))
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg != DefaultConfig() {
+		t.Errorf("config = %+v, want defaults %+v", cfg, DefaultConfig())
+	}
+	if cfg.ReadHeaderTimeout <= 0 || cfg.IdleTimeout <= 0 || cfg.MaxHeaderBytes <= 0 {
+		t.Errorf("defaults must enable timeouts and header limit, got %+v", cfg)
+	}
+}
+
+func TestLoadConfig_EnvOverrides(t *testing.T) {
+	cfg, err := LoadConfig(envMap(map[string]string{
+		"HOST":                "127.0.0.1",
+		"PORT":                "9000",
+		"READ_TIMEOUT":        "3s",
+		"READ_HEADER_TIMEOUT": "250ms",
+		"WRITE_TIMEOUT":       "1m",
+		"IDLE_TIMEOUT":        "0s",
+		"MAX_HEADER_BYTES":    "4096",
+		"MAX_BODY_BYTES":      "1024",
+		"MAX_CONNS":           "10",
+		"COMPRESSION":         "false",
+		"COMPRESS_MIN_BYTES":  "256",
+		"COMPRESS_LEVEL":      "9",
+	}))
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+
+	want := DefaultConfig()
+	want.Host = "127.0.0.1"
+	want.Port = "9000"
+	want.ReadTimeout = 3 * time.Second
+	want.ReadHeaderTimeout = 250 * time.Millisecond
+	want.WriteTimeout = time.Minute
+	want.IdleTimeout = 0
+	want.MaxHeaderBytes = 4096
+	want.MaxBodyBytes = 1024
+	want.MaxConns = 10
+	want.Compression = false
+	want.CompressMinBytes = 256
+	want.CompressLevel = 9
+	if cfg != want {
+		t.Errorf("config = %+v, want %+v", cfg, want)
+	}
+	if cfg.addr() != "127.0.0.1:9000" {
+		t.Errorf("addr = %s, want 127.0.0.1:9000", cfg.addr())
+	}
+}
+
+func TestLoadConfig_EmptyValues(t *testing.T) {
+	// Set but empty: a setting tagged allowempty is cleared, like with its
+	// flag; every other setting keeps its default.
+	cfg, err := LoadConfig(envMap(map[string]string{
+		"MAINTENANCE_FILE":  "",
+		"PORT":              "",
+		"HOST":              "",
+		"LOG_LEVEL":         "",
+		"ACCESS_LOG_FORMAT": "",
+		"TIMEZONE":          "",
+		"DATA_DIR":          "",
+		"READ_TIMEOUT":      "",
+	}))
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	want := DefaultConfig()
+	want.MaintenanceFile = ""
+	if cfg != want {
+		t.Errorf("config = %+v, want %+v", cfg, want)
+	}
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	testCases := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"bad duration", map[string]string{"READ_TIMEOUT": "soon"}, "READ_TIMEOUT"},
+		{"negative duration", map[string]string{"IDLE_TIMEOUT": "-1s"}, "IDLE_TIMEOUT"},
+		{"negative drain delay", map[string]string{"SHUTDOWN_DRAIN_DELAY": "-1s"}, "SHUTDOWN_DRAIN_DELAY"},
+		{"bad int", map[string]string{"MAX_CONNS": "many"}, "MAX_CONNS"},
+		{"negative body", map[string]string{"MAX_BODY_BYTES": "-5"}, "MAX_BODY_BYTES"},
+		{"bad bool", map[string]string{"COMPRESSION": "maybe"}, "COMPRESSION"},
+		{"level out of range", map[string]string{"COMPRESS_LEVEL": "11"}, "COMPRESS_LEVEL"},
+		{"zero events interval", map[string]string{"EVENTS_INTERVAL": "0s"}, "EVENTS_INTERVAL"},
+		{"zero events buffer", map[string]string{"EVENTS_BUFFER": "0"}, "EVENTS_BUFFER"},
+		{"zero ping interval", map[string]string{"WS_PING_INTERVAL": "0s"}, "WS_PING_INTERVAL"},
+		{"zero log buffer", map[string]string{"LOG_BUFFER_SIZE": "0"}, "LOG_BUFFER_SIZE"},
+		{"unknown log level", map[string]string{"LOG_LEVEL": "loud"}, "LOG_LEVEL"},
+		{"unknown log component", map[string]string{"LOG_COMPONENT_LEVELS": "db=debug"}, "LOG_COMPONENT_LEVELS"},
+		{"zero debug timeout", map[string]string{"LOG_DEBUG_TIMEOUT": "0s"}, "LOG_DEBUG_TIMEOUT"},
+		{"unknown access log format", map[string]string{"ACCESS_LOG_FORMAT": "apache"}, "ACCESS_LOG_FORMAT"},
+		{"sample rate above 1", map[string]string{"ACCESS_LOG_HEALTH_SAMPLE": "1.5"}, "ACCESS_LOG_HEALTH_SAMPLE"},
+		{"track with a space", map[string]string{"DEPLOY_TRACK": "canary one"}, "DEPLOY_TRACK"},
+		{"bad chaos rules", map[string]string{"CHAOS_RULES": `[{"route": "/", "error_rate": 2}]`}, "CHAOS_RULES"},
+		{"shed max below min", map[string]string{"SHED_MIN_LIMIT": "10", "SHED_MAX_LIMIT": "5"}, "SHED_MAX_LIMIT"},
+		{"zero shed queue timeout", map[string]string{"SHED_QUEUE_TIMEOUT": "0s"}, "SHED_QUEUE_TIMEOUT"},
+		{"bad maintenance end", map[string]string{"MAINTENANCE_UNTIL": "tomorrow"}, "MAINTENANCE_UNTIL"},
+		{"NTP server without a host", map[string]string{"NTP_SERVER": ":123"}, "NTP_SERVER"},
+		{"NTP server with a bad port", map[string]string{"NTP_SERVER": "time.example.com:ntp"}, "NTP_SERVER"},
+		{"zero NTP timeout", map[string]string{"NTP_TIMEOUT": "0s"}, "NTP_TIMEOUT"},
+		{"zero NTP max skew", map[string]string{"NTP_MAX_SKEW": "0s"}, "NTP_MAX_SKEW"},
+		{"unknown timezone", map[string]string{"TIMEZONE": "Mars/Olympus_Mons"}, "TIMEZONE"},
+		{"host timezone", map[string]string{"TIMEZONE": "Local"}, "TIMEZONE"},
+		{"bad port", map[string]string{"PORT": "http"}, "PORT"},
+		{"port out of range", map[string]string{"PORT": "70000"}, "PORT"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := LoadConfig(envMap(tc.env))
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("error %q does not mention %s", err, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package infoservice
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// The contract lives outside the module because app_python is held to it
+// too; see contract/record_python.sh for refreshing the Python fixtures.
+const contractDir = "../../../contract"
+
+// contractCases pairs each Go request with the recorded Python response
+// for the same request and the schema both must satisfy.
+var contractCases = []struct {
+	name    string
+	method  string
+	target  string
+	status  int
+	def     string
+	fixture string
+}{
+	{"info", http.MethodGet, "/", http.StatusOK, "ServiceInfo", "root.json"},
+	{"health", http.MethodGet, "/health", http.StatusOK, "Health", "health.json"},
+	{"visits", http.MethodGet, "/visits", http.StatusOK, "Visits", "visits.json"},
+	{"not found", http.MethodGet, "/nope", http.StatusNotFound, "Error", "error_404.json"},
+	{"method not allowed", http.MethodPost, "/health", http.StatusMethodNotAllowed, "Error", "error_405.json"},
+}
+
+// knownDifferences are the flattened keys one implementation has and the
+// other does not, on purpose. Anything else in the key-set comparison is
+// drift.
+var knownDifferences = map[string]bool{
+	"system.go_version":     true,
+	"system.python_version": true,
+	"message":               true, // app_go error bodies
+	"status_code":           true, // app_python error bodies
+	"path":                  true, // app_python error bodies
+}
+
+func loadContractSchema(t *testing.T) map[string]interface{} {
+	t.Helper()
+	var schema map[string]interface{}
+	loadJSONFile(t, filepath.Join(contractDir, "schema.json"), &schema)
+	return schema
+}
+
+func loadJSONFile(t *testing.T, path string, v interface{}) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("parse %s: %v", path, err)
+	}
+}
+
+func contractDef(def string) interface{} {
+	return map[string]interface{}{"$ref": "#/$defs/" + def}
+}
+
+// responseShape flattens v into its set of key paths. Array elements share
+// one "[]" path, so lists of different length still compare equal.
+func responseShape(prefix string, v interface{}, out map[string]bool) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			key := k
+			if prefix != "" {
+				key = prefix + "." + k
+			}
+			responseShape(key, child, out)
+		}
+	case []interface{}:
+		for _, child := range val {
+			responseShape(prefix+"[]", child, out)
+		}
+	default:
+		out[prefix] = true
+	}
+}
+
+func shapeDiff(goBody, pyBody interface{}) []string {
+	goKeys, pyKeys := map[string]bool{}, map[string]bool{}
+	responseShape("", goBody, goKeys)
+	responseShape("", pyBody, pyKeys)
+
+	var diffs []string
+	for k := range goKeys {
+		if !pyKeys[k] && !knownDifferences[k] {
+			diffs = append(diffs, "only in app_go: "+k)
+		}
+	}
+	for k := range pyKeys {
+		if !goKeys[k] && !knownDifferences[k] {
+			diffs = append(diffs, "only in app_python: "+k)
+		}
+	}
+	sort.Strings(diffs)
+	return diffs
+}
+
+func TestContract_GoConforms(t *testing.T) {
+	schema := loadContractSchema(t)
+	s := newTestServer(t)
+	withVisitStore(t, s)
+	h := s.Handler()
+
+	for _, tc := range contractCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(tc.method, tc.target, nil))
+			if w.Code != tc.status {
+				t.Fatalf("status = %d, want %d", w.Code, tc.status)
+			}
+			if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+				t.Errorf("Content-Type = %q, want application/json", ct)
+			}
+
+			var body interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if errs := (schemaValidator{root: schema}).validate(contractDef(tc.def), body, "$"); len(errs) > 0 {
+				t.Errorf("app_go response violates the contract:\n%s", strings.Join(errs, "\n"))
+			}
+		})
+	}
+}
+
+func TestContract_PythonFixturesConform(t *testing.T) {
+	schema := loadContractSchema(t)
+
+	for _, tc := range contractCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var body interface{}
+			loadJSONFile(t, filepath.Join(contractDir, "fixtures", "python", tc.fixture), &body)
+			if errs := (schemaValidator{root: schema}).validate(contractDef(tc.def), body, "$"); len(errs) > 0 {
+				t.Errorf("%s violates the contract:\n%s", tc.fixture, strings.Join(errs, "\n"))
+			}
+		})
+	}
+}
+
+func TestContract_NoDrift(t *testing.T) {
+	s := newTestServer(t)
+	withVisitStore(t, s)
+	h := s.Handler()
+
+	for _, tc := range contractCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(tc.method, tc.target, nil))
+			var goBody, pyBody interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &goBody); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			loadJSONFile(t, filepath.Join(contractDir, "fixtures", "python", tc.fixture), &pyBody)
+
+			if diffs := shapeDiff(goBody, pyBody); len(diffs) > 0 {
+				t.Errorf("app_go and app_python disagree on the response shape:\n%s", strings.Join(diffs, "\n"))
+			}
+		})
+	}
+}
+
+func TestContract_DetectsDrift(t *testing.T) {
+	schema := loadContractSchema(t)
+	v := schemaValidator{root: schema}
+
+	// A Python-style naive timestamp and a renamed field must both fail.
+	health := map[string]interface{}{"status": "healthy", "timestamp": "2026-01-26T07:32:25.101932", "uptime_secs": 1.0}
+	joined := strings.Join(v.validate(contractDef("Health"), health, "$"), "\n")
+	for _, want := range []string{"RFC 3339", `missing required property "uptime_seconds"`, "uptime_secs: unexpected property"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("errors %q do not mention %q", joined, want)
+		}
+	}
+
+	diffs := shapeDiff(
+		map[string]interface{}{"status": "healthy", "uptime_seconds": 1.0},
+		map[string]interface{}{"status": "healthy", "uptime": 1.0},
+	)
+	if len(diffs) != 2 {
+		t.Errorf("shapeDiff = %v, want both renamed keys reported", diffs)
+	}
+}
@@ -0,0 +1,59 @@
+// Package infoservice is the DevOps info service as a library. The
+// devops-info-service command runs it on its own; other Go programs can
+// mount its handlers in their own mux instead:
+//
+//	srv, err := infoservice.NewServer()
+//	mux.Handle("/info", srv.InfoHandler())
+//	mux.Handle("/healthz", srv.HealthHandler())
+//	http.ListenAndServe(":8080", srv.Middleware(mux))
+//
+// The exported identifiers below, NewServer and its options, Config,
+// RunCLI and the response types are the stable API; everything else may
+// change between releases.
+package infoservice
+
+import (
+	"context"
+	"net/http"
+)
+
+// ==================== EMBEDDING ====================
+
+// InfoHandler serves the service information (ServiceInfo) for GET
+// requests on whatever path it is mounted at.
+func (s *Server) InfoHandler() http.Handler {
+	return http.HandlerFunc(s.infoHandler)
+}
+
+// HealthHandler serves the liveness check (HealthResp).
+func (s *Server) HealthHandler() http.Handler {
+	return http.HandlerFunc(s.healthHandler)
+}
+
+// ReadyHandler serves the readiness check: 200 with ReadyResp, or 503
+// with the reasons while the process is not ready.
+func (s *Server) ReadyHandler() http.Handler {
+	return http.HandlerFunc(s.readyHandler)
+}
+
+// MetricsHandler serves the Prometheus metrics.
+func (s *Server) MetricsHandler() http.Handler {
+	return http.HandlerFunc(s.metricsHandler)
+}
+
+// RequestID gives every request an ID, taken from a valid X-Request-ID
+// header or generated, and echoes it in the response.
+func RequestID(next http.Handler) http.Handler {
+	return withRequestID(next)
+}
+
+// RequestIDFrom returns the ID RequestID stored in ctx, or "".
+func RequestIDFrom(ctx context.Context) string {
+	return requestIDFrom(ctx)
+}
+
+// Recover turns a panic in next into a 500 JSON error carrying the
+// request ID, logs it and counts it under route in devops_panics_total.
+func (s *Server) Recover(route string, next http.Handler) http.Handler {
+	return s.recoverPanics(route, next.ServeHTTP)
+}
@@ -0,0 +1,52 @@
+package infoservice
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestInfoHandler_ServesAnyPath(t *testing.T) {
+	s := newTestServer(t)
+	for _, path := range []string{"/", "/info", "/internal/about"} {
+		w := serveGet(s.InfoHandler().ServeHTTP, path, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want 200", path, w.Code)
+		}
+		var info ServiceInfo
+		if err := json.Unmarshal(w.Body.Bytes(), &info); err != nil {
+			t.Fatal(err)
+		}
+		if info.Request.Path != path {
+			t.Errorf("request.path = %q, want %q", info.Request.Path, path)
+		}
+	}
+
+	// Mounted at "/" by Handler, only "/" itself is the info document.
+	if w := serveGet(s.mainHandler, "/info", nil); w.Code != http.StatusNotFound {
+		t.Errorf("mainHandler /info status = %d, want 404", w.Code)
+	}
+}
+
+func TestMiddleware_SetsRequestID(t *testing.T) {
+	var seen string
+	h := newTestServer(t).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestIDFrom(r.Context())
+	}))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	r.Header.Set("X-Request-ID", "abc-123")
+	h.ServeHTTP(w, r)
+	if seen != "abc-123" || w.Header().Get("X-Request-ID") != "abc-123" {
+		t.Errorf("request ID = %q, header %q, want abc-123", seen, w.Header().Get("X-Request-ID"))
+	}
+	if w.Header().Get("X-Served-By") == "" {
+		t.Error("X-Served-By missing")
+	}
+	if RequestIDFrom(context.Background()) != "" {
+		t.Error("RequestIDFrom without an ID is not empty")
+	}
+}
@@ -0,0 +1,346 @@
+package infoservice
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ==================== EVENTS (SSE) ====================
+
+// countRequests counts every request s handles; /events and /ws turn the
+// count into a rate.
+func (s *Server) countRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// runtimeStats is the data of a "stats" event.
+type runtimeStats struct {
+	UptimeSeconds     int     `json:"uptime_seconds"`
+	Goroutines        int     `json:"goroutines"`
+	HeapAllocBytes    uint64  `json:"heap_alloc_bytes"`
+	HeapInuseBytes    uint64  `json:"heap_inuse_bytes"`
+	SysBytes          uint64  `json:"sys_bytes"`
+	NumGC             uint32  `json:"num_gc"`
+	RequestsPerSecond float64 `json:"requests_per_second"`
+	Ready             bool    `json:"ready"`
+}
+
+// readinessEvent is the data of a "readiness" event, sent as soon as the
+// readiness state changes rather than on the next tick.
+type readinessEvent struct {
+	Ready   bool     `json:"ready"`
+	Reasons []string `json:"reasons"`
+}
+
+type sseEvent struct {
+	ID   uint64
+	Name string
+	Data []byte
+}
+
+// eventBroker samples runtime stats and fans events out to /events
+// clients. Each client has a bounded queue; a client that falls behind is
+// disconnected instead of slowing the others down, and can resume from
+// history with Last-Event-ID.
+type eventBroker struct {
+	uptime       func() time.Duration
+	now          func() time.Time
+	readiness    *readinessState
+	requests     *atomic.Uint64
+	log          *logger
+	interval     time.Duration
+	heartbeat    time.Duration
+	writeTimeout time.Duration
+	bufferSize   int
+	historySize  int
+
+	mu      sync.Mutex
+	lastID  uint64
+	history []sseEvent
+	clients map[chan sseEvent]struct{}
+	closed  bool
+	done    chan struct{}
+
+	sampler *statsSampler // only touched by the run goroutine
+}
+
+// newEventBroker builds the broker for srv, whose uptime, readiness and
+// request rate it reports.
+func newEventBroker(srv *Server) *eventBroker {
+	cfg := srv.cfg
+	return &eventBroker{
+		uptime:       srv.elapsed,
+		now:          srv.now,
+		readiness:    srv.readiness,
+		requests:     &srv.requests,
+		log:          srv.log,
+		interval:     cfg.EventsInterval,
+		heartbeat:    cfg.EventsHeartbeat,
+		writeTimeout: cfg.WriteTimeout,
+		bufferSize:   cfg.EventsBuffer,
+		historySize:  cfg.EventsHistory,
+		clients:      map[chan sseEvent]struct{}{},
+		done:         make(chan struct{}),
+	}
+}
+
+// run publishes a stats event every interval and a readiness event on every
+// readiness change, until ctx is done or the broker is closed.
+func (b *eventBroker) run(ctx context.Context) {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	b.sampler = newStatsSampler(b.uptime, b.now, b.readiness, b.requests)
+	_, _, changed := b.readiness.Status()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-b.done:
+			return
+		case <-ticker.C:
+			b.publish("stats", b.sampler.sample())
+		case <-changed:
+			var ev readinessEvent
+			ev.Ready, ev.Reasons, changed = b.readiness.Status()
+			b.publish("readiness", ev)
+		}
+	}
+}
+
+// statsSampler reads runtime stats and turns the request counter into a
+// rate since the previous sample.
+type statsSampler struct {
+	uptime     func() time.Duration
+	now        func() time.Time
+	readiness  *readinessState
+	requests   *atomic.Uint64
+	lastCount  uint64
+	lastSample time.Time
+}
+
+func newStatsSampler(uptime func() time.Duration, now func() time.Time, readiness *readinessState, requests *atomic.Uint64) *statsSampler {
+	return &statsSampler{uptime: uptime, now: now, readiness: readiness, requests: requests, lastCount: requests.Load(), lastSample: now()}
+}
+
+func (s *statsSampler) sample() runtimeStats {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	now, count := s.now(), s.requests.Load()
+	var rate float64
+	if elapsed := now.Sub(s.lastSample).Seconds(); elapsed > 0 {
+		rate = float64(count-s.lastCount) / elapsed
+	}
+	s.lastCount, s.lastSample = count, now
+
+	ready, _, _ := s.readiness.Status()
+	return runtimeStats{
+		UptimeSeconds:     int(s.uptime().Seconds()),
+		Goroutines:        runtime.NumGoroutine(),
+		HeapAllocBytes:    mem.HeapAlloc,
+		HeapInuseBytes:    mem.HeapInuse,
+		SysBytes:          mem.Sys,
+		NumGC:             mem.NumGC,
+		RequestsPerSecond: float64(int(rate*100)) / 100,
+		Ready:             ready,
+	}
+}
+
+func (b *eventBroker) publish(name string, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		b.log.errorf("events", "Error encoding %s event: %v", name, err)
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.lastID++
+	ev := sseEvent{ID: b.lastID, Name: name, Data: data}
+	if b.historySize > 0 {
+		b.history = append(b.history, ev)
+		if len(b.history) > b.historySize {
+			b.history = b.history[len(b.history)-b.historySize:]
+		}
+	}
+
+	for ch := range b.clients {
+		select {
+		case ch <- ev:
+		default:
+			b.log.warnf("events", "Dropping slow /events client after %d queued events", b.bufferSize)
+			delete(b.clients, ch)
+			close(ch)
+		}
+	}
+}
+
+// subscribe registers a client. With resume set it returns the events
+// after lastID still in history; otherwise, or when lastID is from an
+// earlier process, just the latest event so the client has data at once.
+func (b *eventBroker) subscribe(lastID uint64, resume bool) (chan sseEvent, []sseEvent, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, nil, false
+	}
+
+	var backlog []sseEvent
+	if resume && lastID <= b.lastID {
+		for _, ev := range b.history {
+			if ev.ID > lastID {
+				backlog = append(backlog, ev)
+			}
+		}
+	} else if n := len(b.history); n > 0 {
+		backlog = b.history[n-1:]
+	}
+
+	ch := make(chan sseEvent, b.bufferSize)
+	b.clients[ch] = struct{}{}
+	return ch, backlog, true
+}
+
+func (b *eventBroker) unsubscribe(ch chan sseEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.clients[ch]; ok {
+		delete(b.clients, ch)
+		close(ch)
+	}
+}
+
+// Close ends every stream. Serve registers it with RegisterOnShutdown:
+// Shutdown waits for handlers to return, and these never would on their own.
+func (b *eventBroker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	close(b.done)
+	for ch := range b.clients {
+		delete(b.clients, ch)
+		close(ch)
+	}
+}
+
+func (b *eventBroker) isClosed() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.closed
+}
+
+// lastEventID reads the Last-Event-ID header, or the lastEventId query
+// parameter for clients that cannot set headers.
+func lastEventID(r *http.Request) (uint64, bool, error) {
+	raw := r.Header.Get("Last-Event-ID")
+	if raw == "" {
+		raw = r.URL.Query().Get("lastEventId")
+	}
+	if raw == "" {
+		return 0, false, nil
+	}
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid Last-Event-ID %q", raw)
+	}
+	return id, true, nil
+}
+
+func (s *Server) eventsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, r, http.StatusMethodNotAllowed, "Only GET method is allowed for this endpoint")
+		return
+	}
+	b := s.events
+	if b == nil {
+		writeJSONError(w, r, http.StatusServiceUnavailable, "Event stream is not available")
+		return
+	}
+	lastID, resume, err := lastEventID(r)
+	if err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ch, backlog, ok := b.subscribe(lastID, resume)
+	if !ok {
+		writeJSONError(w, r, http.StatusServiceUnavailable, "Server is shutting down")
+		return
+	}
+	defer b.unsubscribe(ch)
+
+	rc := http.NewResponseController(w)
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Accel-Buffering", "no") // keep nginx from buffering the stream
+	w.WriteHeader(http.StatusOK)
+
+	// The stream outlives WRITE_TIMEOUT, so every write gets its own
+	// deadline instead: a client that stops reading is cut off.
+	send := func(format string, args ...interface{}) bool {
+		var deadline time.Time
+		if b.writeTimeout > 0 {
+			deadline = time.Now().Add(b.writeTimeout)
+		}
+		rc.SetWriteDeadline(deadline)
+		if _, err := fmt.Fprintf(w, format, args...); err != nil {
+			return false
+		}
+		return rc.Flush() == nil
+	}
+
+	if !send("retry: %d\n\n", b.interval.Milliseconds()) {
+		return
+	}
+	for _, ev := range backlog {
+		if !send("id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Name, ev.Data) {
+			return
+		}
+	}
+
+	var heartbeat <-chan time.Time
+	if b.heartbeat > 0 {
+		t := time.NewTicker(b.heartbeat)
+		defer t.Stop()
+		heartbeat = t.C
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev, ok := <-ch:
+			if !ok {
+				if b.isClosed() {
+					send(": server shutting down\n\n")
+				}
+				return
+			}
+			if !send("id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Name, ev.Data) {
+				return
+			}
+		case <-heartbeat:
+			if !send(": heartbeat\n\n") {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package infoservice
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withEventBroker returns a Server built from cfg with its event broker in
+// place. The sampler is not started; tests publish or call run themselves.
+func withEventBroker(t *testing.T, cfg Config) *Server {
+	t.Helper()
+	s := newTestServer(t, WithConfig(cfg))
+	s.events = newEventBroker(s)
+	t.Cleanup(s.events.Close)
+	return s
+}
+
+type sseMessage struct {
+	id, event, data, comment string
+}
+
+// readSSE reads one blank-line terminated block from the stream.
+func readSSE(t *testing.T, r *bufio.Reader) sseMessage {
+	t.Helper()
+	var msg sseMessage
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("stream ended: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return msg
+		}
+		field, value, _ := strings.Cut(line, ": ")
+		switch field {
+		case "":
+			msg.comment = value
+		case "id":
+			msg.id = value
+		case "event":
+			msg.event = value
+		case "data":
+			msg.data = value
+		}
+	}
+}
+
+// openStream connects to /events on a test server and skips the retry
+// hint that starts every stream.
+func openStream(t *testing.T, s *Server, header map[string]string) (*http.Response, *bufio.Reader) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(s.eventsHandler))
+	t.Cleanup(srv.Close)
+
+	req, _ := http.NewRequest("GET", srv.URL+"/events", nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /events: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+
+	r := bufio.NewReader(resp.Body)
+	if line, _ := r.ReadString('\n'); !strings.HasPrefix(line, "retry: ") {
+		t.Fatalf("first line = %q, want a retry hint", line)
+	}
+	r.ReadString('\n')
+	return resp, r
+}
+
+func TestEvents_StreamsStats(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.EventsInterval = 20 * time.Millisecond
+	s := withEventBroker(t, cfg)
+	b := s.events
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go b.run(ctx)
+
+	resp, r := openStream(t, s, nil)
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+	if cc := resp.Header.Get("Cache-Control"); cc != "no-cache" {
+		t.Errorf("Cache-Control = %q, want no-cache", cc)
+	}
+
+	msg := readSSE(t, r)
+	if msg.event != "stats" || msg.id == "" {
+		t.Fatalf("event = %+v, want a stats event with an id", msg)
+	}
+	var stats runtimeStats
+	if err := json.Unmarshal([]byte(msg.data), &stats); err != nil {
+		t.Fatalf("invalid stats JSON %q: %v", msg.data, err)
+	}
+	if stats.Goroutines == 0 || stats.SysBytes == 0 || !stats.Ready {
+		t.Errorf("stats = %+v, want goroutines, memory and ready filled in", stats)
+	}
+}
+
+func TestStatsSampler_CountsOwnRequests(t *testing.T) {
+	clock := newFakeClock(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	a, b := newTestServer(t, WithClock(clock)), newTestServer(t, WithClock(clock))
+	sampleA := newStatsSampler(a.elapsed, a.now, a.readiness, &a.requests)
+	sampleB := newStatsSampler(b.elapsed, b.now, b.readiness, &b.requests)
+
+	h := a.Handler()
+	for i := 0; i < 4; i++ {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+	}
+	clock.Advance(2 * time.Second)
+	if got := sampleA.sample().RequestsPerSecond; got != 2 {
+		t.Errorf("requests_per_second = %v, want 2", got)
+	}
+	if got := sampleB.sample().RequestsPerSecond; got != 0 {
+		t.Errorf("another Server's requests_per_second = %v, want 0", got)
+	}
+}
+
+func TestEvents_ReadinessChangeSentImmediately(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.EventsInterval = time.Hour
+	s := withEventBroker(t, cfg)
+	b := s.events
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go b.run(ctx)
+
+	_, r := openStream(t, s, nil)
+	s.readiness.Set("test", "Draining for rollout")
+
+	msg := readSSE(t, r)
+	if msg.event != "readiness" || msg.data != `{"ready":false,"reasons":["Draining for rollout"]}` {
+		t.Errorf("event = %+v, want the readiness change", msg)
+	}
+}
+
+func TestEvents_ResumeFromLastEventID(t *testing.T) {
+	s := withEventBroker(t, DefaultConfig())
+	b := s.events
+	for i := 1; i <= 5; i++ {
+		b.publish("stats", map[string]int{"n": i})
+	}
+
+	_, r := openStream(t, s, map[string]string{"Last-Event-ID": "3"})
+	for _, want := range []string{"4", "5"} {
+		if msg := readSSE(t, r); msg.id != want {
+			t.Errorf("replayed id = %s, want %s", msg.id, want)
+		}
+	}
+
+	b.publish("stats", map[string]int{"n": 6})
+	if msg := readSSE(t, r); msg.id != "6" || msg.data != `{"n":6}` {
+		t.Errorf("live event = %+v, want id 6", msg)
+	}
+}
+
+func TestEvents_Backlog(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.EventsHistory = 3
+	s := withEventBroker(t, cfg)
+	b := s.events
+	for i := 0; i < 5; i++ {
+		b.publish("stats", i)
+	}
+
+	testCases := []struct {
+		name    string
+		lastID  uint64
+		resume  bool
+		wantIDs []uint64
+	}{
+		{"new client gets the latest event", 0, false, []uint64{5}},
+		{"resume within history", 3, true, []uint64{4, 5}},
+		{"resume older than history", 1, true, []uint64{3, 4, 5}},
+		{"up to date", 5, true, nil},
+		{"id from an earlier process", 99, true, []uint64{5}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ch, backlog, ok := b.subscribe(tc.lastID, tc.resume)
+			if !ok {
+				t.Fatal("subscribe refused")
+			}
+			defer b.unsubscribe(ch)
+
+			var ids []uint64
+			for _, ev := range backlog {
+				ids = append(ids, ev.ID)
+			}
+			if len(ids) != len(tc.wantIDs) {
+				t.Fatalf("backlog ids = %v, want %v", ids, tc.wantIDs)
+			}
+			for i := range ids {
+				if ids[i] != tc.wantIDs[i] {
+					t.Errorf("backlog ids = %v, want %v", ids, tc.wantIDs)
+				}
+			}
+		})
+	}
+}
+
+func TestEvents_SlowClientDropped(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.EventsBuffer = 2
+	s := withEventBroker(t, cfg)
+	b := s.events
+
+	slow, _, _ := b.subscribe(0, false)
+	fast, _, _ := b.subscribe(0, false)
+	for i := 0; i < 3; i++ {
+		b.publish("stats", i)
+		<-fast
+	}
+
+	// The slow client got its two buffered events, then its channel closed.
+	n := 0
+	for range slow {
+		n++
+	}
+	if n != 2 {
+		t.Errorf("slow client received %d events, want 2", n)
+	}
+
+	b.publish("stats", 3)
+	if ev := <-fast; ev.ID != 4 {
+		t.Errorf("fast client got id %d, want 4", ev.ID)
+	}
+}
+
+func TestEvents_Heartbeat(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.EventsHeartbeat = 20 * time.Millisecond
+	s := withEventBroker(t, cfg)
+
+	_, r := openStream(t, s, nil)
+	if msg := readSSE(t, r); msg.comment != "heartbeat" {
+		t.Errorf("message = %+v, want a heartbeat comment", msg)
+	}
+}
+
+func TestEvents_CloseEndsStream(t *testing.T) {
+	s := withEventBroker(t, DefaultConfig())
+	b := s.events
+	_, r := openStream(t, s, nil)
+
+	b.Close()
+	if msg := readSSE(t, r); msg.comment != "server shutting down" {
+		t.Errorf("message = %+v, want the shutdown comment", msg)
+	}
+	if _, err := r.ReadString('\n'); err == nil {
+		t.Error("stream still open after Close")
+	}
+
+	if _, _, ok := b.subscribe(0, false); ok {
+		t.Error("closed broker accepted a subscriber")
+	}
+}
+
+func TestEventsHandler_Errors(t *testing.T) {
+	if w := serveGet(newTestServer(t).eventsHandler, "/events", nil); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("without a broker: status = %d, want 503", w.Code)
+	}
+
+	s := withEventBroker(t, DefaultConfig())
+	if w := serveGet(s.eventsHandler, "/events", map[string]string{"Last-Event-ID": "abc"}); w.Code != http.StatusBadRequest {
+		t.Errorf("bad Last-Event-ID: status = %d, want 400", w.Code)
+	}
+	w := httptest.NewRecorder()
+	s.eventsHandler(w, httptest.NewRequest("POST", "/events", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: status = %d, want 405", w.Code)
+	}
+}
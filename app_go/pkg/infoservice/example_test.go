@@ -0,0 +1,114 @@
+package infoservice_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"devops-info-service/pkg/infoservice"
+)
+
+// get sends a GET request for path to h and returns the response.
+func get(h http.Handler, path string) *http.Response {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w.Result()
+}
+
+// Mount the info and health handlers next to your own routes and wrap the
+// mux in the service middleware.
+func Example() {
+	srv, err := infoservice.NewServer(
+		infoservice.WithConfig(infoservice.DefaultConfig()),
+		infoservice.WithHostname(func() (string, error) { return "orders-7f9c", nil }),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orders", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "[]")
+	})
+	mux.Handle("/internal/info", srv.InfoHandler())
+	mux.Handle("/internal/health", srv.HealthHandler())
+	handler := srv.Middleware(mux)
+
+	resp := get(handler, "/internal/info")
+	var info infoservice.ServiceInfo
+	json.NewDecoder(resp.Body).Decode(&info)
+	fmt.Println(resp.StatusCode, info.Service.Name, info.System.Hostname, info.Request.Path)
+
+	resp = get(handler, "/orders")
+	fmt.Println(resp.StatusCode, resp.Header.Get("X-Request-ID") != "")
+	// Output:
+	// 200 devops-info-service orders-7f9c /internal/info
+	// 200 true
+}
+
+// stepClock is a Clock that moves only when told to.
+type stepClock struct{ now time.Time }
+
+func (c *stepClock) Now() time.Time { return c.now }
+
+// Uptime counts from the first request, when the server begins serving.
+func ExampleServer_HealthHandler() {
+	clock := &stepClock{now: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+	srv, err := infoservice.NewServer(
+		infoservice.WithConfig(infoservice.DefaultConfig()),
+		infoservice.WithClock(clock),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		var health infoservice.HealthResp
+		json.NewDecoder(get(srv.HealthHandler(), "/healthz").Body).Decode(&health)
+		fmt.Println(health.Status, health.Timestamp, health.UptimeSeconds)
+		clock.now = clock.now.Add(90 * time.Second)
+	}
+	// Output:
+	// healthy 2026-03-01T12:00:00Z 0
+	// healthy 2026-03-01T12:01:30Z 90
+}
+
+// The whole service, every route included, can live under a prefix.
+func ExampleServer_Handler() {
+	srv, err := infoservice.NewServer(infoservice.WithConfig(infoservice.DefaultConfig()))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/devops/", http.StripPrefix("/devops", srv.Handler()))
+
+	fmt.Println(get(mux, "/devops/health").StatusCode)
+	fmt.Println(get(mux, "/devops/no-such-route").StatusCode)
+	// Output:
+	// 200
+	// 404
+}
+
+func ExampleServer_Recover() {
+	srv, err := infoservice.NewServer(
+		infoservice.WithConfig(infoservice.DefaultConfig()),
+		infoservice.WithLogger(func(string, ...interface{}) {}),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	h := infoservice.RequestID(srv.Recover("/boom", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("bug")
+	})))
+
+	resp := get(h, "/boom")
+	var body infoservice.ErrorResp
+	json.NewDecoder(resp.Body).Decode(&body)
+	fmt.Println(resp.StatusCode, body.Error)
+	// Output: 500 Internal Server Error
+}
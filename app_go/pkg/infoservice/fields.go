@@ -0,0 +1,160 @@
+package infoservice
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// ==================== FIELD SELECTION ====================
+
+// fieldSelection is the parsed ?fields= and ?sections= query: a trie of
+// json field names. A nil child means "the whole subtree".
+type fieldSelection struct {
+	root map[string]*selectionNode
+}
+
+type selectionNode struct {
+	children map[string]*selectionNode
+}
+
+// parseFieldSelection reads ?fields=system.hostname,runtime.uptime_seconds
+// and ?sections=system,runtime and validates every path against the json
+// layout of t. It returns nil when the client asked for everything.
+func parseFieldSelection(r *http.Request, t reflect.Type) (*fieldSelection, error) {
+	q := r.URL.Query()
+	fields, sections := splitList(q.Get("fields")), splitList(q.Get("sections"))
+	if len(fields) == 0 && len(sections) == 0 {
+		return nil, nil
+	}
+
+	sel := &fieldSelection{root: make(map[string]*selectionNode)}
+	var unknown []string
+	for _, name := range sections {
+		if strings.Contains(name, ".") || !validFieldPath(t, []string{name}) {
+			unknown = append(unknown, name)
+			continue
+		}
+		sel.add([]string{name})
+	}
+	for _, field := range fields {
+		path := strings.Split(field, ".")
+		if !validFieldPath(t, path) {
+			unknown = append(unknown, field)
+			continue
+		}
+		sel.add(path)
+	}
+
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("unknown fields: %s", strings.Join(unknown, ", "))
+	}
+	return sel, nil
+}
+
+func splitList(raw string) []string {
+	var out []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// add records path; a shorter path that is already selected wins over a
+// longer one below it (system + system.hostname means all of system).
+func (s *fieldSelection) add(path []string) {
+	level := s.root
+	for i, name := range path {
+		node, seen := level[name]
+		last := i == len(path)-1
+		switch {
+		case seen && node == nil:
+			return
+		case last:
+			level[name] = nil
+			return
+		case !seen:
+			node = &selectionNode{children: make(map[string]*selectionNode)}
+			level[name] = node
+		}
+		level = node.children
+	}
+}
+
+// includes reports whether anything inside the top-level section is
+// selected, so handlers can skip computing sections nobody asked for.
+func (s *fieldSelection) includes(section string) bool {
+	if s == nil {
+		return true
+	}
+	_, ok := s.root[section]
+	return ok
+}
+
+// apply prunes a tree from toTree down to the selected fields, keeping the
+// original field order. Selections below a list apply to every item.
+func (s *fieldSelection) apply(tree interface{}) interface{} {
+	if s == nil {
+		return tree
+	}
+	return pruneTree(tree, s.root)
+}
+
+func pruneTree(node interface{}, selected map[string]*selectionNode) interface{} {
+	switch n := node.(type) {
+	case *orderedMap:
+		out := newOrderedMap()
+		for _, k := range n.Keys() {
+			child, ok := selected[k]
+			if !ok {
+				continue
+			}
+			value, _ := n.Get(k)
+			if child == nil {
+				out.Set(k, value)
+			} else {
+				out.Set(k, pruneTree(value, child.children))
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(n))
+		for i, item := range n {
+			out[i] = pruneTree(item, selected)
+		}
+		return out
+	}
+	return node
+}
+
+// validFieldPath walks the json field names of t along path. Lists are
+// transparent: endpoints.path names the path of every endpoint.
+func validFieldPath(t reflect.Type, path []string) bool {
+	for _, name := range path {
+		for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return false
+		}
+		field, ok := fieldByJSONName(t, name)
+		if !ok {
+			return false
+		}
+		t = field.Type
+	}
+	return true
+}
+
+func fieldByJSONName(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath == "" && strings.Split(f.Tag.Get("json"), ",")[0] == name {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
@@ -0,0 +1,168 @@
+package infoservice
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func decodeInfo(t *testing.T, target string) (int, map[string]interface{}) {
+	t.Helper()
+	w := serveGet(newTestServer(t).mainHandler, target, nil)
+	var data map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&data); err != nil {
+		t.Fatalf("%s: invalid JSON: %v", target, err)
+	}
+	return w.Code, data
+}
+
+func keysOf(m interface{}) []string {
+	obj, _ := m.(map[string]interface{})
+	var keys []string
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func TestFieldSelection_Sections(t *testing.T) {
+	code, data := decodeInfo(t, "/?sections=system,runtime")
+	if code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", code)
+	}
+	if len(data) != 2 || data["system"] == nil || data["runtime"] == nil {
+		t.Errorf("expected only system and runtime, got %v", keysOf(data))
+	}
+	if system := data["system"].(map[string]interface{}); system["cpu_count"] == nil {
+		t.Error("a whole section should keep all its fields")
+	}
+}
+
+func TestFieldSelection_NestedFields(t *testing.T) {
+	testCases := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			"two nested fields",
+			"fields=system.hostname,service.name",
+			`{"service":{"name":"devops-info-service"},"system":{"hostname":"h"}}`,
+		},
+		{
+			"field inside list",
+			"fields=endpoints.path",
+			`{"endpoints":[{"path":"/"},{"path":"/health"},{"path":"/ready"},{"path":"/visits"},{"path":"/events"},{"path":"/ws"},{"path":"/metrics"},{"path":"/openapi.json"}]}`,
+		},
+		{
+			"order follows the response, not the query",
+			"fields=request.method,service.version",
+			`{"service":{"version":"` + version + `"},"request":{"method":"GET"}}`,
+		},
+		{
+			"duplicates collapse",
+			"fields=request.path,request.path",
+			`{"request":{"path":"/"}}`,
+		},
+	}
+
+	srv := newTestServer(t, WithHostname(func() (string, error) { return "h", nil }))
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := serveGet(srv.mainHandler, "/?format=compact&"+tc.query, nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != tc.want {
+				t.Errorf("body = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFieldSelection_SectionWinsOverItsFields(t *testing.T) {
+	_, data := decodeInfo(t, "/?fields=service.name,system.hostname&sections=system")
+	service, _ := data["service"].(map[string]interface{})
+	system, _ := data["system"].(map[string]interface{})
+	if len(data) != 2 || len(service) != 1 || len(system) != 6 {
+		t.Errorf("unexpected selection: service=%v system=%v", service, system)
+	}
+}
+
+func TestFieldSelection_SkipsUnrequestedSections(t *testing.T) {
+	called := false
+	srv := newTestServer(t, WithHostname(func() (string, error) {
+		called = true
+		return "h", nil
+	}))
+
+	serveGet(srv.mainHandler, "/?sections=runtime", nil)
+	if called {
+		t.Error("hostname was looked up although system was not requested")
+	}
+
+	serveGet(srv.mainHandler, "/?fields=system.platform", nil)
+	if !called {
+		t.Error("hostname lookup expected when the system section is requested")
+	}
+}
+
+func TestFieldSelection_UnknownFields(t *testing.T) {
+	for _, query := range []string{
+		"fields=system.nope",
+		"fields=nope",
+		"fields=system.hostname.deeper",
+		"sections=system.hostname",
+		"sections=bogus",
+	} {
+		w := serveGet(newTestServer(t).mainHandler, "/?"+query, nil)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", query, w.Code)
+			continue
+		}
+		var data map[string]string
+		json.NewDecoder(w.Body).Decode(&data)
+		if !strings.Contains(data["message"], "unknown fields") {
+			t.Errorf("%s: message = %q", query, data["message"])
+		}
+	}
+}
+
+func TestFieldSelection_WithVolatileExcluded(t *testing.T) {
+	_, data := decodeInfo(t, "/?sections=runtime&volatile=false")
+	runtime, _ := data["runtime"].(map[string]interface{})
+	if len(runtime) != 2 || runtime["timezone"] == nil || runtime["utc_offset"] == nil {
+		t.Errorf("expected only runtime.timezone and runtime.utc_offset, got %v", runtime)
+	}
+}
+
+func TestValidFieldPath(t *testing.T) {
+	typ := reflect.TypeOf(ServiceInfo{})
+	valid := [][]string{{"system"}, {"system", "hostname"}, {"endpoints"}, {"endpoints", "description"}}
+	invalid := [][]string{{"System"}, {"system", "cpu"}, {"runtime", "timezone", "name"}, {"endpoints", "0"}}
+
+	for _, path := range valid {
+		if !validFieldPath(typ, path) {
+			t.Errorf("%v should be valid", path)
+		}
+	}
+	for _, path := range invalid {
+		if validFieldPath(typ, path) {
+			t.Errorf("%v should be invalid", path)
+		}
+	}
+}
+
+func TestParseFieldSelection_Empty(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/?fields=&sections=", nil)
+	sel, err := parseFieldSelection(req, reflect.TypeOf(ServiceInfo{}))
+	if sel != nil || err != nil {
+		t.Errorf("empty selection = (%v, %v), want (nil, nil)", sel, err)
+	}
+	if !sel.includes("system") {
+		t.Error("a nil selection includes every section")
+	}
+}
@@ -0,0 +1,75 @@
+package infoservice
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ==================== HEALTHCHECK ====================
+
+// The runtime image is distroless (no shell, no curl), so the binary probes
+// itself: `devops-info-service healthcheck` exits 0 when the endpoint
+// answers 2xx and 1 otherwise, which is what Docker HEALTHCHECK expects.
+
+// defaultProbeURL points at the /health endpoint of the instance described
+// by cfg. Wildcard bind addresses are probed over loopback.
+func defaultProbeURL(cfg Config) string {
+	host := strings.TrimSuffix(strings.TrimPrefix(cfg.Host, "["), "]")
+	switch host {
+	case "", "0.0.0.0", "::":
+		host = "127.0.0.1"
+	}
+	return "http://" + net.JoinHostPort(host, cfg.Port) + "/health"
+}
+
+func runHealthcheck(args []string, lookupEnv func(string) (string, bool), stderr io.Writer) int {
+	cfg, err := LoadConfig(lookupEnv)
+	if err != nil {
+		fmt.Fprintf(stderr, "healthcheck: %v\n", err)
+		return exitFailure
+	}
+
+	fs := flag.NewFlagSet("healthcheck", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	url := fs.String("url", defaultProbeURL(cfg), "URL to probe")
+	timeout := fs.Duration("timeout", 2*time.Second, "probe timeout")
+	if code := parseFlags(fs, args); code >= 0 {
+		return code
+	}
+
+	if err := probe(*url, *timeout); err != nil {
+		fmt.Fprintf(stderr, "healthcheck: %v\n", err)
+		return exitFailure
+	}
+	return exitOK
+}
+
+// probe sends a GET to url and succeeds only on a 2xx answer within timeout.
+func probe(url string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "devops-info-service-healthcheck")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	return nil
+}
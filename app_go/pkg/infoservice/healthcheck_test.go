@@ -0,0 +1,85 @@
+package infoservice
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDefaultProbeURL(t *testing.T) {
+	testCases := []struct {
+		host string
+		port string
+		want string
+	}{
+		{"0.0.0.0", "8000", "http://127.0.0.1:8000/health"},
+		{"", "8080", "http://127.0.0.1:8080/health"},
+		{"::", "8000", "http://127.0.0.1:8000/health"},
+		{"10.0.0.5", "9000", "http://10.0.0.5:9000/health"},
+		{"[::]", "8000", "http://127.0.0.1:8000/health"},
+		{"::1", "8000", "http://[::1]:8000/health"},
+		{"[::1]", "8000", "http://[::1]:8000/health"},
+		{"fd00::5", "9000", "http://[fd00::5]:9000/health"},
+	}
+
+	for _, tc := range testCases {
+		cfg := DefaultConfig()
+		cfg.Host, cfg.Port = tc.host, tc.port
+		if got := defaultProbeURL(cfg); got != tc.want {
+			t.Errorf("defaultProbeURL(%q, %q) = %s, want %s", tc.host, tc.port, got, tc.want)
+		}
+	}
+}
+
+func TestRunHealthcheck(t *testing.T) {
+	srv := newTestServer(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			srv.healthHandler(w, r)
+		case "/down":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/slow":
+			time.Sleep(500 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"healthy", []string{"--url", server.URL + "/health"}, 0},
+		{"unhealthy status", []string{"--url", server.URL + "/down"}, 1},
+		{"timeout", []string{"--url", server.URL + "/slow", "--timeout", "50ms"}, 1},
+		{"unreachable", []string{"--url", "http://127.0.0.1:1/health", "--timeout", "1s"}, 1},
+		{"bad flag", []string{"--nope"}, 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var stderr bytes.Buffer
+			if got := runHealthcheck(tc.args, envMap(nil), &stderr); got != tc.want {
+				t.Errorf("exit code = %d, want %d (stderr: %s)", got, tc.want, stderr.String())
+			}
+			if tc.want == 1 && !strings.Contains(stderr.String(), "healthcheck:") {
+				t.Errorf("expected failure reason on stderr, got %q", stderr.String())
+			}
+		})
+	}
+}
+
+func TestRunHealthcheck_UsesConfigPort(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(newTestServer(t).healthHandler))
+	defer server.Close()
+	port := server.URL[strings.LastIndex(server.URL, ":")+1:]
+
+	var stderr bytes.Buffer
+	if got := runHealthcheck(nil, envMap(map[string]string{"PORT": port}), &stderr); got != 0 {
+		t.Errorf("exit code = %d, want 0 (stderr: %s)", got, stderr.String())
+	}
+}
@@ -0,0 +1,178 @@
+package infoservice
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ==================== LOCALIZATION ====================
+
+// Human-readable text (uptime_human and error messages) follows ?lang= or
+// Accept-Language. Messages are looked up by their English text, so one
+// without a translation, or in a language we do not have, stays English.
+// The error field and everything else a program would match on stay
+// English too.
+
+// pluralForm is a CLDR plural category.
+type pluralForm int
+
+const (
+	pluralOne pluralForm = iota
+	pluralFew
+	pluralMany
+	pluralOther
+)
+
+type locale struct {
+	tag    string
+	plural func(n int64) pluralForm
+	// units names day, hour, minute and second in each plural form.
+	units    map[string]map[pluralForm]string
+	messages map[string]string
+}
+
+var english = &locale{
+	tag: "en",
+	plural: func(n int64) pluralForm {
+		if n == 1 {
+			return pluralOne
+		}
+		return pluralOther
+	},
+	units: map[string]map[pluralForm]string{
+		"day":    {pluralOne: "day", pluralOther: "days"},
+		"hour":   {pluralOne: "hour", pluralOther: "hours"},
+		"minute": {pluralOne: "minute", pluralOther: "minutes"},
+		"second": {pluralOne: "second", pluralOther: "seconds"},
+	},
+}
+
+var russian = &locale{
+	tag: "ru",
+	// 1, 21, 101 день; 2-4, 22-24 дня; 0, 5-20, 25-30, 111-114 дней.
+	plural: func(n int64) pluralForm {
+		switch mod10, mod100 := n%10, n%100; {
+		case mod10 == 1 && mod100 != 11:
+			return pluralOne
+		case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+			return pluralFew
+		default:
+			return pluralMany
+		}
+	},
+	units: map[string]map[pluralForm]string{
+		"day":    {pluralOne: "день", pluralFew: "дня", pluralMany: "дней"},
+		"hour":   {pluralOne: "час", pluralFew: "часа", pluralMany: "часов"},
+		"minute": {pluralOne: "минута", pluralFew: "минуты", pluralMany: "минут"},
+		"second": {pluralOne: "секунда", pluralFew: "секунды", pluralMany: "секунд"},
+	},
+	messages: map[string]string{
+		"Only GET method is allowed for this endpoint":                                  "Для этого адреса разрешён только метод GET",
+		"Only GET and PUT methods are allowed for this endpoint":                        "Для этого адреса разрешены только методы GET и PUT",
+		"Endpoint does not exist":                                                       "Такого адреса нет",
+		"volatile must be true or false":                                                "volatile должен быть true или false",
+		"uptime_format must be one of %s":                                               "uptime_format должен быть одним из: %s",
+		"Supported formats: %s (use the Accept header or the ?format= query parameter)": "Поддерживаемые форматы: %s (укажите заголовок Accept или параметр ?format=)",
+		"Unknown timezone %q, use an IANA name such as Europe/Moscow":                   "Неизвестный часовой пояс %q, укажите имя из базы IANA, например Europe/Moscow",
+		"Clock is off by more than %s from NTP server %s":                               "Часы расходятся с NTP-сервером %[2]s больше чем на %[1]s",
+		"Failed to render response":                                                     "Не удалось сформировать ответ",
+		"Request body is too large":                                                     "Тело запроса слишком большое",
+		"Server is overloaded, retry later":                                             "Сервер перегружен, повторите запрос позже",
+		"Server is shutting down":                                                       "Сервер останавливается",
+		"Service is down for maintenance":                                               "Сервис на техническом обслуживании",
+		"The server hit an unexpected error, quote the request ID when reporting it":    "На сервере произошла непредвиденная ошибка, укажите ID запроса, когда будете о ней сообщать",
+		"Visit counter is not available":                                                "Счётчик посещений недоступен",
+		"Failed to read visit counter":                                                  "Не удалось прочитать счётчик посещений",
+		"Event stream is not available":                                                 "Поток событий недоступен",
+		"Log tail is not available":                                                     "Хвост журнала недоступен",
+		"WebSocket endpoint is not available":                                           "WebSocket недоступен",
+		"This endpoint speaks WebSocket":                                                "Этот адрес работает по WebSocket",
+		"Origin not allowed":                                                            "Источник запроса (Origin) не разрешён",
+		"WebSocket upgrade failed":                                                      "Не удалось переключиться на WebSocket",
+		"Admin API is disabled, set ADMIN_TOKEN to enable it":                           "API администратора выключен, задайте ADMIN_TOKEN, чтобы его включить",
+		"Missing or invalid admin token":                                                "Токен администратора не передан или неверен",
+		"Chaos mode is disabled, set CHAOS_ENABLED=true to enable it":                   "Режим хаоса выключен, задайте CHAOS_ENABLED=true, чтобы его включить",
+		"Injected by chaos mode":                                                        "Ошибка внесена режимом хаоса",
+	},
+}
+
+// locales lists the supported languages; the first is the fallback.
+var locales = []*locale{english, russian}
+
+func localeTags() []string {
+	var tags []string
+	for _, l := range locales {
+		tags = append(tags, l.tag)
+	}
+	return tags
+}
+
+// localeByTag matches the primary subtag, so ru-RU finds ru.
+func localeByTag(tag string) (*locale, bool) {
+	primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	for _, l := range locales {
+		if l.tag == primary {
+			return l, true
+		}
+	}
+	return nil, false
+}
+
+// negotiateLocale picks the language for r: a supported ?lang= wins, then
+// the best supported Accept-Language entry, then English.
+func negotiateLocale(r *http.Request) *locale {
+	if l, ok := localeByTag(r.URL.Query().Get("lang")); ok {
+		return l
+	}
+
+	type langItem struct {
+		tag string
+		q   float64
+	}
+	var items []langItem
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if raw, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(raw, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > 0 {
+			items = append(items, langItem{tag: tag, q: q})
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].q > items[j].q })
+	for _, item := range items {
+		if l, ok := localeByTag(item.tag); ok {
+			return l
+		}
+	}
+	return locales[0]
+}
+
+// message translates an English message, or returns it unchanged.
+func (l *locale) message(msg string) string {
+	if t, ok := l.messages[msg]; ok {
+		return t
+	}
+	return msg
+}
+
+// messagef translates an English format string and fills it in.
+func (l *locale) messagef(format string, args ...interface{}) string {
+	return fmt.Sprintf(l.message(format), args...)
+}
+
+// unit writes n with the word for unit in the matching plural form.
+func (l *locale) unit(n int64, unit string) string {
+	forms := l.units[unit]
+	word, ok := forms[l.plural(n)]
+	if !ok {
+		word = forms[pluralOther]
+	}
+	return strconv.FormatInt(n, 10) + " " + word
+}
@@ -0,0 +1,138 @@
+package infoservice
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRussianPlural(t *testing.T) {
+	for _, tt := range []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 дней"},
+		{1, "1 день"},
+		{2, "2 дня"},
+		{4, "4 дня"},
+		{5, "5 дней"},
+		{11, "11 дней"},
+		{12, "12 дней"},
+		{14, "14 дней"},
+		{21, "21 день"},
+		{22, "22 дня"},
+		{25, "25 дней"},
+		{101, "101 день"},
+		{111, "111 дней"},
+		{112, "112 дней"},
+		{122, "122 дня"},
+	} {
+		if got := russian.unit(tt.n, "day"); got != tt.want {
+			t.Errorf("unit(%d, day) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+	if got := russian.unit(3, "minute"); got != "3 минуты" {
+		t.Errorf("unit(3, minute) = %q", got)
+	}
+	if got := english.unit(1, "second") + ", " + english.unit(0, "second"); got != "1 second, 0 seconds" {
+		t.Errorf("english units = %q", got)
+	}
+}
+
+func TestFormatUptime_Russian(t *testing.T) {
+	d := 21*24*time.Hour + 2*time.Hour + 11*time.Minute + 33*time.Second
+	for format, want := range map[string]string{
+		"human":   "506 часов, 11 минут",
+		"full":    "21 день, 2 часа, 11 минут, 33 секунды",
+		"iso8601": "P21DT2H11M33S",
+		"compact": "21d2h11m33s",
+	} {
+		if got := formatUptime(d, format, russian); got != want {
+			t.Errorf("%s: %q, want %q", format, got, want)
+		}
+	}
+}
+
+func TestNegotiateLocale(t *testing.T) {
+	for _, tt := range []struct {
+		query, acceptLanguage, want string
+	}{
+		{"", "", "en"},
+		{"", "ru", "ru"},
+		{"", "ru-RU,ru;q=0.9,en-US;q=0.8", "ru"},
+		{"", "en-GB,en;q=0.9,ru;q=0.8", "en"},
+		{"", "de-DE,ru;q=0.5", "ru"},
+		{"", "de, fr", "en"},
+		{"", "ru;q=0, en;q=0.1", "en"},
+		{"", "*", "en"},
+		{"?lang=ru", "en", "ru"},
+		{"?lang=RU-ru", "", "ru"},
+		{"?lang=en", "ru", "en"},
+		{"?lang=de", "ru", "ru"},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/"+tt.query, nil)
+		r.Header.Set("Accept-Language", tt.acceptLanguage)
+		if got := negotiateLocale(r).tag; got != tt.want {
+			t.Errorf("%s Accept-Language %q: %s, want %s", tt.query, tt.acceptLanguage, got, tt.want)
+		}
+	}
+}
+
+func TestMainHandler_Localized(t *testing.T) {
+	s := withClock(t, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), 2*24*time.Hour+time.Hour+time.Minute)
+
+	for _, tt := range []struct {
+		target, acceptLanguage, want, lang string
+	}{
+		{"/", "", "49 hours, 1 minutes", "en"},
+		{"/", "ru-RU,ru;q=0.9", "49 часов, 1 минута", "ru"},
+		{"/?uptime_format=full", "ru", "2 дня, 1 час, 1 минута, 0 секунд", "ru"},
+		{"/?uptime_format=full&lang=en", "ru", "2 days, 1 hour, 1 minute, 0 seconds", "en"},
+		{"/?lang=ru", "", "49 часов, 1 минута", "ru"},
+		{"/?lang=fr", "", "49 hours, 1 minutes", "en"},
+	} {
+		w := serveGet(s.mainHandler, tt.target, map[string]string{"Accept-Language": tt.acceptLanguage})
+		var info ServiceInfo
+		if err := json.Unmarshal(w.Body.Bytes(), &info); err != nil {
+			t.Fatalf("%s: %v", tt.target, err)
+		}
+		if info.Runtime.UptimeHuman != tt.want {
+			t.Errorf("%s %q: uptime_human = %q, want %q", tt.target, tt.acceptLanguage, info.Runtime.UptimeHuman, tt.want)
+		}
+		if got := w.Header().Get("Content-Language"); got != tt.lang {
+			t.Errorf("%s %q: Content-Language = %q, want %s", tt.target, tt.acceptLanguage, got, tt.lang)
+		}
+	}
+}
+
+func TestWriteJSONError_Localized(t *testing.T) {
+	s := newTestServer(t)
+	for _, tt := range []struct {
+		method, target, acceptLanguage, want string
+	}{
+		{http.MethodPost, "/", "ru", "Для этого адреса разрешён только метод GET"},
+		{http.MethodGet, "/nope", "ru", "Такого адреса нет"},
+		{http.MethodGet, "/nope", "de", "Endpoint does not exist"},
+		{http.MethodGet, "/?uptime_format=weeks&lang=ru", "", "uptime_format должен быть одним из: human, full, iso8601, compact"},
+		// Untranslated messages stay English.
+		{http.MethodGet, "/?fields=nope", "ru", "unknown fields: nope"},
+	} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(tt.method, tt.target, nil)
+		r.Header.Set("Accept-Language", tt.acceptLanguage)
+		s.Handler().ServeHTTP(w, r)
+
+		var body ErrorResp
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s %s: %v", tt.method, tt.target, err)
+		}
+		if body.Message != tt.want {
+			t.Errorf("%s %s %q: message = %q, want %q", tt.method, tt.target, tt.acceptLanguage, body.Message, tt.want)
+		}
+		if body.Error != http.StatusText(w.Code) {
+			t.Errorf("%s %s: error = %q, want the English status text", tt.method, tt.target, body.Error)
+		}
+	}
+}
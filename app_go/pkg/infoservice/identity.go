@@ -0,0 +1,72 @@
+package infoservice
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ==================== DEPLOYMENT IDENTITY ====================
+
+// identityFields lists the set fields of d as name/value pairs.
+func (d Deployment) identityFields() [][2]string {
+	var fields [][2]string
+	for _, f := range [][2]string{{"color", d.Color}, {"track", d.Track}, {"revision", d.Revision}} {
+		if f[1] != "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// servedBy is the X-Served-By value: the pod, i.e. the hostname, then the
+// identity, e.g. "myapp-7c9f-x2p; color=green; track=canary; revision=7c9f".
+func servedBy(host string, d Deployment) string {
+	parts := []string{host}
+	for _, f := range d.identityFields() {
+		parts = append(parts, f[0]+"="+f[1])
+	}
+	return strings.Join(parts, "; ")
+}
+
+func (s *Server) withServedBy(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Served-By", servedBy(s.host(), s.deployment))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// metricLabels renders the identity as Prometheus labels with a trailing
+// comma, ready to go before a series' own labels.
+func (d Deployment) metricLabels() string {
+	var sb strings.Builder
+	for _, f := range d.identityFields() {
+		fmt.Fprintf(&sb, "%s=\"%s\",", f[0], labelEscaper.Replace(f[1]))
+	}
+	return sb.String()
+}
+
+// writeDeploymentInfo writes the devops_deployment_info gauge, always 1;
+// its labels let queries join any series to the build and the stack.
+func writeDeploymentInfo(w io.Writer, d Deployment) {
+	fmt.Fprintf(w, "# HELP devops_deployment_info Deployment identity of this instance.\n# TYPE devops_deployment_info gauge\n")
+	fmt.Fprintf(w, "devops_deployment_info{%sversion=\"%s\"} 1\n", d.metricLabels(), labelEscaper.Replace(version))
+}
+
+// validIdentity accepts what fits in a header and a label value without
+// escaping: up to 63 letters, digits and -_.
+func validIdentity(s string) bool {
+	if len(s) > 63 {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-' || c == '_' || c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
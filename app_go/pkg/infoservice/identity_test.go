@@ -0,0 +1,101 @@
+package infoservice
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// deploymentServer builds a Server that identifies as d.
+func deploymentServer(t *testing.T, d Deployment, opts ...Option) *Server {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.DeployColor, cfg.DeployTrack, cfg.DeployRevision = d.Color, d.Track, d.Revision
+	return newTestServer(t, append([]Option{WithConfig(cfg)}, opts...)...)
+}
+
+func TestServedBy(t *testing.T) {
+	testCases := []struct {
+		d    Deployment
+		want string
+	}{
+		{Deployment{Track: "stable"}, "pod-1; track=stable"},
+		{Deployment{Color: "green", Track: "canary", Revision: "7c9f"}, "pod-1; color=green; track=canary; revision=7c9f"},
+		{Deployment{}, "pod-1"},
+	}
+	for _, tc := range testCases {
+		if got := servedBy("pod-1", tc.d); got != tc.want {
+			t.Errorf("servedBy(%+v) = %q, want %q", tc.d, got, tc.want)
+		}
+	}
+}
+
+func TestDeploymentIdentity_Exposed(t *testing.T) {
+	d := Deployment{Color: "blue", Track: "canary", Revision: "5d8b"}
+	h := deploymentServer(t, d, WithHostname(func() (string, error) { return "myapp-5d8b-x2p", nil })).Handler()
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/?sections=deployment", nil))
+	if got := w.Header().Get("X-Served-By"); got != "myapp-5d8b-x2p; color=blue; track=canary; revision=5d8b" {
+		t.Errorf("X-Served-By = %q", got)
+	}
+	var info struct{ Deployment Deployment }
+	if err := json.NewDecoder(w.Body).Decode(&info); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if info.Deployment != d {
+		t.Errorf("deployment section = %+v, want %+v", info.Deployment, d)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body := w.Body.String()
+	for _, want := range []string{
+		`devops_deployment_info{color="blue",track="canary",revision="5d8b",version="` + version + `"} 1`,
+		`devops_log_records_total{color="blue",track="canary",revision="5d8b",level="error"}`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("/metrics lacks %s", want)
+		}
+	}
+}
+
+func TestServedBy_LooksUpHostnameOnce(t *testing.T) {
+	lookups := 0
+	h := deploymentServer(t, Deployment{Track: "stable"}, WithHostname(func() (string, error) {
+		lookups++
+		return "", errors.New("no hostname")
+	}), WithLogger(func(string, ...interface{}) {})).Handler()
+
+	for _, path := range []string{"/health", "/ready", "/", "/nope"} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if got := w.Header().Get("X-Served-By"); got != "unknown; track=stable" {
+			t.Errorf("%s: X-Served-By = %q", path, got)
+		}
+	}
+	if lookups != 1 {
+		t.Errorf("hostname looked up %d times, want 1", lookups)
+	}
+}
+
+func TestDeploymentIdentity_PerServer(t *testing.T) {
+	blue := deploymentServer(t, Deployment{Color: "blue", Track: "stable"})
+	green := deploymentServer(t, Deployment{Color: "green", Track: "canary"})
+
+	for s, want := range map[*Server]string{blue: `color="blue",track="stable"`, green: `color="green",track="canary"`} {
+		if w := serveGet(s.metricsHandler, "/metrics", nil); !strings.Contains(w.Body.String(), "devops_deployment_info{"+want) {
+			t.Errorf("metrics lack %s:\n%s", want, w.Body)
+		}
+	}
+}
+
+func TestValidIdentity(t *testing.T) {
+	for s, want := range map[string]bool{"": true, "green": true, "v1.2-rc_1": true, "canary track": false, `a"b`: false, strings.Repeat("x", 64): false} {
+		if got := validIdentity(s); got != want {
+			t.Errorf("validIdentity(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
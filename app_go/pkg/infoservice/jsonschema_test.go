@@ -0,0 +1,261 @@
+package infoservice
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// schemaValidator checks decoded JSON against the JSON Schema subset the
+// OpenAPI document and the contract schemas use: type, properties,
+// required, additionalProperties, items, enum, const, minimum, pattern,
+// anyOf, oneOf, format date-time and local $ref pointers into root.
+type schemaValidator struct {
+	root map[string]interface{}
+}
+
+// validate returns one message per violation; an empty result means valid.
+func (v schemaValidator) validate(schema interface{}, value interface{}, path string) []string {
+	s, ok := schema.(map[string]interface{})
+	if !ok {
+		return nil // true / {} accept anything
+	}
+
+	if ref, ok := s["$ref"].(string); ok {
+		target, err := v.resolve(ref)
+		if err != nil {
+			return []string{fmt.Sprintf("%s: %v", path, err)}
+		}
+		return v.validate(target, value, path)
+	}
+
+	var errs []string
+	if t, ok := s["type"]; ok && !matchesType(t, value) {
+		return []string{fmt.Sprintf("%s: expected type %v, got %s", path, t, jsonType(value))}
+	}
+
+	if enum, ok := s["enum"].([]interface{}); ok && !containsJSON(enum, value) {
+		errs = append(errs, fmt.Sprintf("%s: %v is not one of %v", path, value, enum))
+	}
+	if c, ok := s["const"]; ok && !equalJSON(c, value) {
+		errs = append(errs, fmt.Sprintf("%s: %v is not %v", path, value, c))
+	}
+	if min, ok := s["minimum"].(float64); ok {
+		if n, isNum := value.(float64); isNum && n < min {
+			errs = append(errs, fmt.Sprintf("%s: %v is below minimum %v", path, n, min))
+		}
+	}
+	if str, isStr := value.(string); isStr {
+		if pattern, ok := s["pattern"].(string); ok && !regexp.MustCompile(pattern).MatchString(str) {
+			errs = append(errs, fmt.Sprintf("%s: %q does not match %s", path, str, pattern))
+		}
+		if s["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339, str); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %q is not an RFC 3339 date-time", path, str))
+			}
+		}
+	}
+
+	if obj, isObj := value.(map[string]interface{}); isObj {
+		props, _ := s["properties"].(map[string]interface{})
+		if required, ok := s["required"].([]interface{}); ok {
+			for _, name := range required {
+				if _, present := obj[name.(string)]; !present {
+					errs = append(errs, fmt.Sprintf("%s: missing required property %q", path, name))
+				}
+			}
+		}
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			childPath := path + "." + k
+			if propSchema, ok := props[k]; ok {
+				errs = append(errs, v.validate(propSchema, obj[k], childPath)...)
+				continue
+			}
+			switch extra := s["additionalProperties"].(type) {
+			case bool:
+				if !extra {
+					errs = append(errs, fmt.Sprintf("%s: unexpected property", childPath))
+				}
+			case map[string]interface{}:
+				errs = append(errs, v.validate(extra, obj[k], childPath)...)
+			}
+		}
+	}
+
+	if arr, isArr := value.([]interface{}); isArr {
+		if items, ok := s["items"]; ok {
+			for i, item := range arr {
+				errs = append(errs, v.validate(items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+
+	if anyOf, ok := s["anyOf"].([]interface{}); ok {
+		matched := false
+		for _, alt := range anyOf {
+			if len(v.validate(alt, value, path)) == 0 {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			errs = append(errs, fmt.Sprintf("%s: matches none of anyOf", path))
+		}
+	}
+	if oneOf, ok := s["oneOf"].([]interface{}); ok {
+		matched := 0
+		for _, alt := range oneOf {
+			if len(v.validate(alt, value, path)) == 0 {
+				matched++
+			}
+		}
+		if matched != 1 {
+			errs = append(errs, fmt.Sprintf("%s: matches %d of oneOf, want exactly 1", path, matched))
+		}
+	}
+	return errs
+}
+
+// resolve follows a local JSON pointer such as #/components/schemas/HealthResp.
+func (v schemaValidator) resolve(ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported $ref %q", ref)
+	}
+	var node interface{} = v.root
+	for _, part := range strings.Split(ref[2:], "/") {
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		obj, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("$ref %q does not resolve", ref)
+		}
+		if node, ok = obj[part]; !ok {
+			return nil, fmt.Errorf("$ref %q does not resolve", ref)
+		}
+	}
+	return node, nil
+}
+
+func matchesType(t interface{}, value interface{}) bool {
+	if list, ok := t.([]interface{}); ok {
+		for _, item := range list {
+			if matchesType(item, value) {
+				return true
+			}
+		}
+		return false
+	}
+	want, _ := t.(string)
+	got := jsonType(value)
+	if want == "number" && got == "integer" {
+		return true
+	}
+	return want == got
+}
+
+func jsonType(value interface{}) string {
+	switch val := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if val == math.Trunc(val) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func containsJSON(list []interface{}, value interface{}) bool {
+	for _, item := range list {
+		if equalJSON(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func equalJSON(a, b interface{}) bool {
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return string(ja) == string(jb)
+}
+
+// toJSONValue round-trips v through encoding/json so Go values compare the
+// same way decoded documents do.
+func toJSONValue(t *testing.T, v interface{}) map[string]interface{} {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	return out
+}
+
+func TestSchemaValidator(t *testing.T) {
+	root := map[string]interface{}{
+		"defs": map[string]interface{}{
+			"Item": map[string]interface{}{
+				"type":                 "object",
+				"required":             []interface{}{"id", "at"},
+				"additionalProperties": false,
+				"properties": map[string]interface{}{
+					"id":   map[string]interface{}{"type": "integer", "minimum": 1.0},
+					"at":   map[string]interface{}{"type": "string", "format": "date-time"},
+					"kind": map[string]interface{}{"enum": []interface{}{"a", "b"}},
+					"ver":  map[string]interface{}{"anyOf": []interface{}{map[string]interface{}{"type": "string"}, map[string]interface{}{"type": "null"}}},
+				},
+			},
+		},
+	}
+	v := schemaValidator{root: root}
+	schema := map[string]interface{}{"type": "array", "items": map[string]interface{}{"$ref": "#/defs/Item"}}
+
+	valid := []interface{}{
+		map[string]interface{}{"id": 1.0, "at": "2026-01-26T07:32:24.854178+00:00", "kind": "a", "ver": nil},
+		map[string]interface{}{"id": 2.0, "at": "2026-01-26T07:32:24Z"},
+	}
+	if errs := v.validate(schema, valid, "$"); len(errs) > 0 {
+		t.Errorf("valid document rejected: %v", errs)
+	}
+
+	invalid := []struct {
+		value interface{}
+		want  string
+	}{
+		{map[string]interface{}{"at": "2026-01-26T07:32:24Z"}, `missing required property "id"`},
+		{map[string]interface{}{"id": 0.0, "at": "2026-01-26T07:32:24Z"}, "below minimum"},
+		{map[string]interface{}{"id": 1.5, "at": "2026-01-26T07:32:24Z"}, "expected type integer"},
+		{map[string]interface{}{"id": 1.0, "at": "yesterday"}, "date-time"},
+		{map[string]interface{}{"id": 1.0, "at": "2026-01-26T07:32:24Z", "kind": "c"}, "is not one of"},
+		{map[string]interface{}{"id": 1.0, "at": "2026-01-26T07:32:24Z", "extra": true}, "unexpected property"},
+		{map[string]interface{}{"id": 1.0, "at": "2026-01-26T07:32:24Z", "ver": 3.0}, "anyOf"},
+	}
+	for _, tc := range invalid {
+		errs := v.validate(schema, []interface{}{tc.value}, "$")
+		if len(errs) == 0 || !strings.Contains(strings.Join(errs, "; "), tc.want) {
+			t.Errorf("%v: errors %v, want one containing %q", tc.value, errs, tc.want)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package infoservice
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ==================== LOGGING ====================
+
+type logLevel int
+
+const (
+	levelDebug logLevel = iota
+	levelInfo
+	levelWarn
+	levelError
+)
+
+var logLevelNames = []string{"debug", "info", "warn", "error"}
+
+func (l logLevel) String() string {
+	if l < levelDebug || l > levelError {
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+	return logLevelNames[l]
+}
+
+func parseLogLevel(s string) (logLevel, error) {
+	for i, name := range logLevelNames {
+		if strings.EqualFold(s, name) {
+			return logLevel(i), nil
+		}
+	}
+	if strings.EqualFold(s, "warning") {
+		return levelWarn, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q, valid: %s", s, strings.Join(logLevelNames, ", "))
+}
+
+// logRecord is one structured log entry as kept by the log tail and sent
+// to /ws clients.
+type logRecord struct {
+	Seq       uint64                 `json:"seq"`
+	Time      string                 `json:"time" format:"date-time"`
+	Level     string                 `json:"level"`
+	Component string                 `json:"component,omitempty"`
+	Message   string                 `json:"message"`
+	Fields    map[string]interface{} `json:"fields,omitempty"`
+
+	at    time.Time
+	level logLevel
+}
+
+// logComponents are the components the service logs under.
+var logComponents = []string{"server", "http", "health", "storage", "events", "ws"}
+
+// logger turns log calls into records, writes them as text through printf
+// and hands them to every registered sink. Records below the level set for
+// their component are dropped. Each Server has its own.
+type logger struct {
+	seq    atomic.Uint64
+	levels *logLevels
+	printf func(format string, v ...interface{})
+	now    func() time.Time
+	mu     sync.RWMutex
+	sinks  map[int]func(logRecord)
+	next   int
+}
+
+// newLogger writes through printf and stamps records with now; nil means
+// log.Printf and time.Now.
+func newLogger(printf func(format string, v ...interface{}), now func() time.Time) *logger {
+	if printf == nil {
+		printf = log.Printf
+	}
+	if now == nil {
+		now = time.Now
+	}
+	return &logger{levels: newLogLevels(), printf: printf, now: now, sinks: map[int]func(logRecord){}}
+}
+
+// addSink registers fn for every record and returns a function that
+// removes it again. Sinks run synchronously and must not block.
+func (l *logger) addSink(fn func(logRecord)) func() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	id := l.next
+	l.next++
+	l.sinks[id] = fn
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.sinks, id)
+	}
+}
+
+func (l *logger) log(level logLevel, component string, fields map[string]interface{}, format string, args ...interface{}) {
+	if !l.levels.enabled(level, component) {
+		return
+	}
+	logRecordsTotal.inc(level.String())
+
+	msg := fmt.Sprintf(format, args...)
+	now := l.now()
+
+	prefix := "[" + strings.ToUpper(level.String()) + "] "
+	if component != "" {
+		prefix += component + ": "
+	}
+	l.printf("%s%s%s", prefix, msg, formatLogFields(fields))
+
+	rec := logRecord{
+		Seq:       l.seq.Add(1),
+		Time:      now.UTC().Format(time.RFC3339Nano),
+		Level:     level.String(),
+		Component: component,
+		Message:   msg,
+		Fields:    fields,
+		at:        now,
+		level:     level,
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for _, sink := range l.sinks {
+		sink(rec)
+	}
+}
+
+// formatLogFields renders structured fields for the text output, one
+// key=value per field in a stable order; multi-line values such as stack
+// traces go on their own lines.
+func formatLogFields(fields map[string]interface{}) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	var inline, block []string
+	for _, k := range sortedKeys(fields) {
+		v := fmt.Sprint(fields[k])
+		if strings.Contains(v, "\n") {
+			block = append(block, k+":\n"+v)
+		} else {
+			inline = append(inline, fmt.Sprintf("%s=%q", k, v))
+		}
+	}
+	out := ""
+	if len(inline) > 0 {
+		out = " " + strings.Join(inline, " ")
+	}
+	if len(block) > 0 {
+		out += "\n" + strings.Join(block, "\n")
+	}
+	return out
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (l *logger) debugf(component, format string, args ...interface{}) {
+	l.log(levelDebug, component, nil, format, args...)
+}
+
+func (l *logger) infof(component, format string, args ...interface{}) {
+	l.log(levelInfo, component, nil, format, args...)
+}
+
+func (l *logger) warnf(component, format string, args ...interface{}) {
+	l.log(levelWarn, component, nil, format, args...)
+}
+
+func (l *logger) errorf(component, format string, args ...interface{}) {
+	l.log(levelError, component, nil, format, args...)
+}
@@ -0,0 +1,122 @@
+package infoservice
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// discardLogs is a printf for WithLogger and newLogger that drops the text
+// output.
+func discardLogs(string, ...interface{}) {}
+
+// captureLogs returns a printf, for WithLogger or newLogger, that collects
+// the text output in lines.
+func captureLogs() (*[]string, func(format string, args ...interface{})) {
+	var mu sync.Mutex
+	var lines []string
+	return &lines, func(format string, args ...interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+}
+
+func TestParseLogLevel(t *testing.T) {
+	testCases := map[string]logLevel{
+		"debug": levelDebug, "INFO": levelInfo, "warn": levelWarn, "warning": levelWarn, "Error": levelError,
+	}
+	for in, want := range testCases {
+		if got, err := parseLogLevel(in); err != nil || got != want {
+			t.Errorf("parseLogLevel(%q) = (%v, %v), want %v", in, got, err, want)
+		}
+	}
+	if _, err := parseLogLevel("loud"); err == nil || !strings.Contains(err.Error(), "debug, info, warn, error") {
+		t.Errorf("unknown level error = %v, want the valid levels listed", err)
+	}
+}
+
+func TestLogger_TextAndSinks(t *testing.T) {
+	lines, printf := captureLogs()
+	l := newLogger(printf, nil)
+	var got []logRecord
+	remove := l.addSink(func(rec logRecord) { got = append(got, rec) })
+
+	l.warnf("storage", "disk %d%% full", 91)
+	l.log(levelError, "http", map[string]interface{}{"request_id": "abc", "stack": "line 1\nline 2"}, "boom")
+	remove()
+	l.infof("http", "after removal")
+
+	wantLines := []string{
+		"[WARN] storage: disk 91% full",
+		"[ERROR] http: boom request_id=\"abc\"\nstack:\nline 1\nline 2",
+		"[INFO] http: after removal",
+	}
+	if strings.Join(*lines, "|") != strings.Join(wantLines, "|") {
+		t.Errorf("text output = %q, want %q", *lines, wantLines)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("sink got %d records, want 2", len(got))
+	}
+	if got[0].Level != "warn" || got[0].Component != "storage" || got[0].Message != "disk 91% full" || got[0].Time == "" {
+		t.Errorf("record = %+v", got[0])
+	}
+	if got[1].Seq <= got[0].Seq {
+		t.Errorf("sequence numbers not increasing: %d, %d", got[0].Seq, got[1].Seq)
+	}
+	if got[1].Fields["request_id"] != "abc" {
+		t.Errorf("fields = %v", got[1].Fields)
+	}
+}
+
+func TestWithLogger_AdminAndAccessLog(t *testing.T) {
+	lines, printf := captureLogs()
+	cfg := DefaultConfig()
+	cfg.AdminToken = "s3cret"
+	cfg.LogBufferSize = 10
+	s := newTestServer(t, WithConfig(cfg), WithLogger(printf))
+	t.Cleanup(s.logTail.Close)
+	h := s.Handler()
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer s3cret")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	accessLines := func() int {
+		n := 0
+		for _, line := range *lines {
+			if strings.Contains(line, `"GET / HTTP/1.1"`) {
+				n++
+			}
+		}
+		return n
+	}
+
+	serve(http.MethodGet, "/", "")
+	if accessLines() != 1 {
+		t.Fatalf("access log line not sent to the WithLogger printf: %q", *lines)
+	}
+	if w := serve(http.MethodPut, "/admin/log-level", `{"component": "http", "level": "warn"}`); w.Code != http.StatusOK {
+		t.Fatalf("PUT /admin/log-level: status = %d, body %s", w.Code, w.Body)
+	}
+	serve(http.MethodGet, "/", "")
+	if accessLines() != 1 {
+		t.Error("access log still written after http was set to warn")
+	}
+	if got := newTestServer(t).log.levels.snapshot().Components; len(got) != 0 {
+		t.Errorf("another Server's log levels changed: %v", got)
+	}
+
+	var resp LogsResp
+	json.NewDecoder(serve(http.MethodGet, "/admin/logs", "").Body).Decode(&resp)
+	if !strings.Contains(messages(resp.Records), "Log level for http set to warn") {
+		t.Errorf("/admin/logs lacks the level change: %s", messages(resp.Records))
+	}
+}
@@ -0,0 +1,193 @@
+package infoservice
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ==================== LOG LEVELS ====================
+
+// logLevels decides which records are written: a global minimum level,
+// per-component overrides, and a temporary debug mode (SIGUSR1) that
+// reverts on its own.
+type logLevels struct {
+	mu         sync.RWMutex
+	global     logLevel
+	components map[string]logLevel
+	debugUntil time.Time
+	debugTimer *time.Timer
+}
+
+func newLogLevels() *logLevels {
+	return &logLevels{global: levelInfo, components: map[string]logLevel{}}
+}
+
+func (l *logLevels) enabled(level logLevel, component string) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.debugTimer != nil {
+		return true
+	}
+	min, ok := l.components[component]
+	if !ok {
+		min = l.global
+	}
+	return level >= min
+}
+
+// configure replaces the global level and every component override.
+func (l *logLevels) configure(global logLevel, components map[string]logLevel) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.global = global
+	l.components = map[string]logLevel{}
+	for c, level := range components {
+		l.components[c] = level
+	}
+}
+
+// set changes the level of component, or the global level when component
+// is empty. A nil level removes the component's override.
+func (l *logLevels) set(component string, level *logLevel) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	switch {
+	case component == "":
+		l.global = *level
+	case level == nil:
+		delete(l.components, component)
+	default:
+		l.components[component] = *level
+	}
+}
+
+func (l *logLevels) snapshot() LogLevelResp {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	resp := LogLevelResp{Level: l.global.String(), Components: map[string]string{}}
+	for c, level := range l.components {
+		resp.Components[c] = level.String()
+	}
+	if !l.debugUntil.IsZero() {
+		resp.DebugUntil = l.debugUntil.UTC().Format(time.RFC3339)
+	}
+	return resp
+}
+
+// toggleDebug turns debug logging on for everything for d, or off again if
+// it is already on, and reports whether it is now on.
+func (l *logger) toggleDebug(d time.Duration) bool {
+	lv := l.levels
+	lv.mu.Lock()
+	defer lv.mu.Unlock()
+	if lv.debugTimer != nil {
+		lv.debugTimer.Stop()
+		lv.debugTimer, lv.debugUntil = nil, time.Time{}
+		return false
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(d, func() {
+		lv.mu.Lock()
+		expired := lv.debugTimer == timer
+		if expired {
+			lv.debugTimer, lv.debugUntil = nil, time.Time{}
+		}
+		lv.mu.Unlock()
+		if expired {
+			l.infof("server", "Temporary debug logging expired after %s", d)
+		}
+	})
+	lv.debugTimer, lv.debugUntil = timer, l.now().Add(d)
+	return true
+}
+
+// toggleDebugLogging is what SIGUSR1 does.
+func (l *logger) toggleDebugLogging(d time.Duration) {
+	if l.toggleDebug(d) {
+		l.infof("server", "Temporary debug logging on for %s, send SIGUSR1 again to turn it off", d)
+	} else {
+		l.infof("server", "Temporary debug logging turned off")
+	}
+}
+
+// parseComponentLevels reads "http=debug,storage=warn".
+func parseComponentLevels(s string) (map[string]logLevel, error) {
+	levels := map[string]logLevel{}
+	for _, item := range splitList(s) {
+		component, name, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q: want component=level", item)
+		}
+		component = strings.TrimSpace(component)
+		if err := checkLogComponent(component); err != nil {
+			return nil, err
+		}
+		level, err := parseLogLevel(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		levels[component] = level
+	}
+	return levels, nil
+}
+
+func checkLogComponent(component string) error {
+	for _, c := range logComponents {
+		if c == component {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown log component %q, valid: %s", component, strings.Join(logComponents, ", "))
+}
+
+func (s *Server) logLevelHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var req LogLevelUpdate
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			writeJSONError(w, r, http.StatusBadRequest, "Invalid JSON body: "+err.Error())
+			return
+		}
+		if err := s.log.applyLevelUpdate(req); err != nil {
+			writeJSONError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+	default:
+		writeJSONError(w, r, http.StatusMethodNotAllowed, "Only GET and PUT methods are allowed for this endpoint")
+		return
+	}
+	s.writeFormatted(w, r, responseFormats[0], http.StatusOK, s.log.levels.snapshot())
+}
+
+func (l *logger) applyLevelUpdate(req LogLevelUpdate) error {
+	if req.Component != "" {
+		if err := checkLogComponent(req.Component); err != nil {
+			return err
+		}
+		if req.Level == "inherit" {
+			l.levels.set(req.Component, nil)
+			l.infof("server", "Log level override for %s removed", req.Component)
+			return nil
+		}
+	}
+	level, err := parseLogLevel(req.Level)
+	if err != nil {
+		return err
+	}
+	l.levels.set(req.Component, &level)
+
+	target := req.Component
+	if target == "" {
+		target = "all components"
+	}
+	l.infof("server", "Log level for %s set to %s", target, level)
+	return nil
+}
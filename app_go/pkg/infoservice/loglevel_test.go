@@ -0,0 +1,145 @@
+package infoservice
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogLevels_Enabled(t *testing.T) {
+	l := newLogLevels()
+	l.configure(levelWarn, map[string]logLevel{"http": levelError, "storage": levelDebug})
+
+	testCases := []struct {
+		level     logLevel
+		component string
+		want      bool
+	}{
+		{levelInfo, "server", false},
+		{levelWarn, "server", true},
+		{levelWarn, "http", false},
+		{levelError, "http", true},
+		{levelDebug, "storage", true},
+		{levelInfo, "", false},
+	}
+	for _, tc := range testCases {
+		if got := l.enabled(tc.level, tc.component); got != tc.want {
+			t.Errorf("enabled(%s, %q) = %v, want %v", tc.level, tc.component, got, tc.want)
+		}
+	}
+
+	l.set("http", nil)
+	if !l.enabled(levelWarn, "http") {
+		t.Error("http should fall back to the global level after its override is removed")
+	}
+}
+
+func TestLogger_ToggleDebug(t *testing.T) {
+	lg := newLogger(discardLogs, nil)
+	l := lg.levels
+	l.configure(levelInfo, nil)
+
+	if !lg.toggleDebug(time.Minute) || !l.enabled(levelDebug, "http") {
+		t.Fatal("toggleDebug did not turn debug logging on")
+	}
+	if l.snapshot().DebugUntil == "" {
+		t.Error("snapshot does not report debug_until")
+	}
+	if lg.toggleDebug(time.Minute) || l.enabled(levelDebug, "http") {
+		t.Fatal("second toggleDebug did not turn debug logging off")
+	}
+
+	expired := make(chan struct{}, 1)
+	defer lg.addSink(func(rec logRecord) {
+		if strings.HasPrefix(rec.Message, "Temporary debug logging expired") {
+			expired <- struct{}{}
+		}
+	})()
+	lg.toggleDebug(20 * time.Millisecond)
+	select {
+	case <-expired:
+	case <-time.After(2 * time.Second):
+		t.Fatal("debug logging did not revert on its own")
+	}
+	if l.enabled(levelDebug, "http") {
+		t.Error("debug logging still on after it expired")
+	}
+	if got := l.snapshot(); got.Level != "info" || got.DebugUntil != "" {
+		t.Errorf("after revert: %+v", got)
+	}
+}
+
+func TestParseComponentLevels(t *testing.T) {
+	got, err := parseComponentLevels("http=warn, storage = debug")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 || got["http"] != levelWarn || got["storage"] != levelDebug {
+		t.Errorf("parseComponentLevels = %v", got)
+	}
+
+	for _, bad := range []string{"http", "db=info", "http=loud"} {
+		if _, err := parseComponentLevels(bad); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+}
+
+func TestLogLevelHandler(t *testing.T) {
+	lines, printf := captureLogs()
+	s := newTestServer(t, WithLogger(printf))
+
+	put := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.logLevelHandler(w, httptest.NewRequest(http.MethodPut, "/admin/log-level", strings.NewReader(body)))
+		return w
+	}
+	decode := func(w *httptest.ResponseRecorder) LogLevelResp {
+		t.Helper()
+		var resp LogLevelResp
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("invalid JSON: %v", err)
+		}
+		return resp
+	}
+
+	if resp := decode(serveGet(s.logLevelHandler, "/admin/log-level", nil)); resp.Level != "info" || len(resp.Components) != 0 {
+		t.Errorf("GET = %+v, want info without overrides", resp)
+	}
+
+	w := put(`{"level": "error", "component": "http"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT component: status = %d, body %s", w.Code, w.Body)
+	}
+	if resp := decode(w); resp.Components["http"] != "error" {
+		t.Errorf("PUT component = %+v", resp)
+	}
+	*lines = nil
+	s.log.infof("http", "dropped")
+	s.log.infof("health", "kept")
+	if len(*lines) != 1 || !strings.Contains((*lines)[0], "kept") {
+		t.Errorf("logged %q, want only the health line", *lines)
+	}
+
+	if resp := decode(put(`{"level": "inherit", "component": "http"}`)); len(resp.Components) != 0 {
+		t.Errorf("inherit left overrides: %+v", resp)
+	}
+	if resp := decode(put(`{"level": "WARN"}`)); resp.Level != "warn" {
+		t.Errorf("PUT global = %+v", resp)
+	}
+
+	for _, body := range []string{`{"level": "loud"}`, `{"level": "info", "component": "db"}`, `{"level": "inherit"}`, `{"lvl": "info"}`, `not json`} {
+		if w := put(body); w.Code != http.StatusBadRequest {
+			t.Errorf("PUT %s: status = %d, want 400", body, w.Code)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	s.logLevelHandler(w, httptest.NewRequest(http.MethodPost, "/admin/log-level", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: status = %d, want 405", w.Code)
+	}
+}
@@ -0,0 +1,285 @@
+package infoservice
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ==================== LOG TAIL ====================
+
+// logRing keeps the last records in memory, so a pod's recent logs can be
+// read over HTTP even when the log pipeline (Loki) is down. It is a logger
+// sink.
+type logRing struct {
+	mu        sync.Mutex
+	records   []logRecord
+	next      int
+	full      bool
+	followers map[chan logRecord]struct{}
+	closed    bool
+}
+
+const (
+	logFollowBuffer = 256
+	// logFollowWriteTimeout cuts off a follower that stops reading.
+	logFollowWriteTimeout = 10 * time.Second
+)
+
+func newLogRing(size int) *logRing {
+	return &logRing{records: make([]logRecord, size), followers: map[chan logRecord]struct{}{}}
+}
+
+func (r *logRing) add(rec logRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records[r.next] = rec
+	r.next = (r.next + 1) % len(r.records)
+	if r.next == 0 {
+		r.full = true
+	}
+
+	for ch := range r.followers {
+		select {
+		case ch <- rec:
+		default:
+			// Too slow: end the stream rather than block logging.
+			delete(r.followers, ch)
+			close(ch)
+		}
+	}
+}
+
+// snapshot returns the buffered records matching f, oldest first.
+func (r *logRing) snapshot(f logFilter) []logRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.matchLocked(f)
+}
+
+func (r *logRing) matchLocked(f logFilter) []logRecord {
+	var ordered []logRecord
+	if r.full {
+		ordered = append(ordered, r.records[r.next:]...)
+	}
+	ordered = append(ordered, r.records[:r.next]...)
+
+	matched := []logRecord{}
+	for _, rec := range ordered {
+		if f.match(rec) {
+			matched = append(matched, rec)
+		}
+	}
+	if f.limit > 0 && len(matched) > f.limit {
+		matched = matched[len(matched)-f.limit:]
+	}
+	return matched
+}
+
+// follow returns the buffered records matching f and a channel of every
+// record added from then on. The channel is closed when the follower is
+// too slow or the ring is closed.
+func (r *logRing) follow(f logFilter) ([]logRecord, chan logRecord, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil, nil, false
+	}
+	ch := make(chan logRecord, logFollowBuffer)
+	r.followers[ch] = struct{}{}
+	return r.matchLocked(f), ch, true
+}
+
+func (r *logRing) unfollow(ch chan logRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.followers[ch]; ok {
+		delete(r.followers, ch)
+		close(ch)
+	}
+}
+
+// Close ends every follow stream; Server.Serve registers it for shutdown.
+func (r *logRing) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	for ch := range r.followers {
+		delete(r.followers, ch)
+		close(ch)
+	}
+}
+
+// logFilter selects records for /admin/logs.
+type logFilter struct {
+	minLevel  logLevel
+	component string
+	contains  string
+	since     time.Time
+	until     time.Time
+	limit     int
+}
+
+func (f logFilter) match(rec logRecord) bool {
+	if rec.level < f.minLevel {
+		return false
+	}
+	if f.component != "" && rec.Component != f.component {
+		return false
+	}
+	if !f.since.IsZero() && rec.at.Before(f.since) {
+		return false
+	}
+	if !f.until.IsZero() && rec.at.After(f.until) {
+		return false
+	}
+	if f.contains != "" && !strings.Contains(strings.ToLower(rec.Message), f.contains) {
+		return false
+	}
+	return true
+}
+
+// parseLogFilter reads level, component, q, since, until and limit. since
+// and until take an RFC 3339 time or a duration meaning that long before
+// now.
+func parseLogFilter(q url.Values, now time.Time) (logFilter, error) {
+	var f logFilter
+	if v := q.Get("level"); v != "" {
+		level, err := parseLogLevel(v)
+		if err != nil {
+			return f, err
+		}
+		f.minLevel = level
+	}
+	f.component = q.Get("component")
+	f.contains = strings.ToLower(q.Get("q"))
+
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"since", &f.since}, {"until", &f.until}} {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(v); err == nil {
+			*p.dst = now.Add(-d)
+		} else if t, err := time.Parse(time.RFC3339, v); err == nil {
+			*p.dst = t
+		} else {
+			return f, fmt.Errorf("invalid %s %q: want an RFC 3339 time or a duration such as 15m", p.name, v)
+		}
+	}
+
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return f, fmt.Errorf("invalid limit %q: want a positive number", v)
+		}
+		f.limit = n
+	}
+	return f, nil
+}
+
+func (s *Server) logsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, r, http.StatusMethodNotAllowed, "Only GET method is allowed for this endpoint")
+		return
+	}
+	ring := s.logTail
+	if ring == nil {
+		writeJSONError(w, r, http.StatusServiceUnavailable, "Log tail is not available")
+		return
+	}
+	q := r.URL.Query()
+	f, err := parseLogFilter(q, s.now())
+	if err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	follow, err := parseBoolParam(q, "follow")
+	if err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if !follow {
+		s.writeFormatted(w, r, responseFormats[0], http.StatusOK, LogsResp{
+			Capacity: len(ring.records),
+			Records:  ring.snapshot(f),
+		})
+		return
+	}
+	followLogs(w, r, ring, f)
+}
+
+// followLogs streams matching records as newline-delimited JSON, first
+// those already buffered and then new ones, until the client goes away.
+// until and limit only apply to the buffered part.
+func followLogs(w http.ResponseWriter, r *http.Request, ring *logRing, f logFilter) {
+	backlog, ch, ok := ring.follow(f)
+	if !ok {
+		writeJSONError(w, r, http.StatusServiceUnavailable, "Server is shutting down")
+		return
+	}
+	defer ring.unfollow(ch)
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// Like /events, the stream outlives WRITE_TIMEOUT, so every write gets
+	// its own deadline instead.
+	enc := json.NewEncoder(w)
+	send := func(recs ...logRecord) bool {
+		rc.SetWriteDeadline(time.Now().Add(logFollowWriteTimeout))
+		for _, rec := range recs {
+			if err := enc.Encode(rec); err != nil {
+				return false
+			}
+		}
+		return rc.Flush() == nil
+	}
+	if !send(backlog...) {
+		return
+	}
+
+	live := f
+	live.until, live.limit = time.Time{}, 0
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case rec, ok := <-ch:
+			if !ok {
+				return
+			}
+			if !live.match(rec) {
+				continue
+			}
+			if !send(rec) {
+				return
+			}
+		}
+	}
+}
+
+// parseBoolParam reads an optional true/false query parameter.
+func parseBoolParam(q url.Values, name string) (bool, error) {
+	v := q.Get(name)
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s %q: want true or false", name, v)
+	}
+	return b, nil
+}
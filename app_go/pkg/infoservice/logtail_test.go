@@ -0,0 +1,156 @@
+package infoservice
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withLogRing returns a Server whose log tail keeps size records.
+func withLogRing(t *testing.T, size int) *Server {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.LogBufferSize = size
+	s := newTestServer(t, WithConfig(cfg))
+	t.Cleanup(s.logTail.Close)
+	return s
+}
+
+func messages(records []logRecord) string {
+	var out []string
+	for _, rec := range records {
+		out = append(out, rec.Message)
+	}
+	return strings.Join(out, ",")
+}
+
+func TestLogRing_KeepsNewest(t *testing.T) {
+	s := withLogRing(t, 3)
+	for _, m := range []string{"a", "b", "c", "d", "e"} {
+		s.log.infof("test", "%s", m)
+	}
+	if got := messages(s.logTail.snapshot(logFilter{})); got != "c,d,e" {
+		t.Errorf("records = %s, want c,d,e", got)
+	}
+}
+
+func TestLogFilter(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	ring := newLogRing(10)
+
+	add := func(ago time.Duration, level logLevel, component, msg string) {
+		ring.add(logRecord{Message: msg, Component: component, Level: level.String(), level: level, at: now.Add(-ago)})
+	}
+	add(2*time.Hour, levelInfo, "http", "Request: GET /")
+	add(time.Hour, levelWarn, "storage", "Disk almost full")
+	add(30*time.Minute, levelError, "storage", "Error counting visit")
+	add(time.Minute, levelDebug, "health", "Health check from 10.0.0.1")
+
+	testCases := []struct {
+		query string
+		want  string
+	}{
+		{"", "Request: GET /,Disk almost full,Error counting visit,Health check from 10.0.0.1"},
+		{"level=warn", "Disk almost full,Error counting visit"},
+		{"component=storage", "Disk almost full,Error counting visit"},
+		{"q=HEALTH", "Health check from 10.0.0.1"},
+		{"since=45m", "Error counting visit,Health check from 10.0.0.1"},
+		{"until=45m", "Request: GET /,Disk almost full"},
+		{"since=2026-03-01T10:30:00Z&until=2026-03-01T11:45:00Z", "Disk almost full,Error counting visit"},
+		{"limit=1", "Health check from 10.0.0.1"},
+	}
+	for _, tc := range testCases {
+		q, _ := url.ParseQuery(tc.query)
+		f, err := parseLogFilter(q, now)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.query, err)
+		}
+		if got := messages(ring.snapshot(f)); got != tc.want {
+			t.Errorf("%q: records = %s, want %s", tc.query, got, tc.want)
+		}
+	}
+
+	for _, bad := range []string{"level=loud", "since=yesterday", "limit=0", "limit=many"} {
+		q, _ := url.ParseQuery(bad)
+		if _, err := parseLogFilter(q, now); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+}
+
+func TestLogsHandler(t *testing.T) {
+	s := withLogRing(t, 10)
+	s.log.infof("http", "Request: GET /")
+	s.log.errorf("storage", "Error counting visit: disk full")
+
+	w := serveGet(s.logsHandler, "/admin/logs?level=error", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	var resp LogsResp
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if resp.Capacity != 10 || len(resp.Records) != 1 || resp.Records[0].Component != "storage" {
+		t.Errorf("response = %+v", resp)
+	}
+
+	if w := serveGet(s.logsHandler, "/admin/logs?follow=sometimes", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("bad follow: status = %d, want 400", w.Code)
+	}
+}
+
+func TestLogsHandler_Follow(t *testing.T) {
+	s := withLogRing(t, 10)
+	s.log.infof("http", "before")
+	srv := httptest.NewServer(http.HandlerFunc(s.logsHandler))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/admin/logs?follow=true&component=http")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	r := bufio.NewReader(resp.Body)
+	next := func() logRecord {
+		t.Helper()
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("stream ended: %v", err)
+		}
+		var rec logRecord
+		json.Unmarshal([]byte(line), &rec)
+		return rec
+	}
+
+	if rec := next(); rec.Message != "before" {
+		t.Errorf("backlog = %q, want before", rec.Message)
+	}
+	s.log.infof("storage", "filtered out")
+	s.log.infof("http", "after")
+	if rec := next(); rec.Message != "after" {
+		t.Errorf("live record = %q, want after", rec.Message)
+	}
+
+	s.logTail.Close()
+	if _, err := r.ReadString('\n'); err == nil {
+		t.Error("stream still open after Close")
+	}
+}
+
+func TestLogsHandler_Unavailable(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.LogBufferSize = 0
+	s := newTestServer(t, WithConfig(cfg))
+	if w := serveGet(s.logsHandler, "/admin/logs", nil); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", w.Code)
+	}
+}
@@ -0,0 +1,203 @@
+package infoservice
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ==================== MAINTENANCE MODE ====================
+
+// Maintenance mode takes a replica out of rotation without killing it:
+// readiness fails, so the Service stops routing to it, and a request that
+// still reaches / gets a 503 saying why and until when. Probes and admin
+// routes keep working. It is turned on by PUT /admin/maintenance, by the
+// sentinel file MAINTENANCE_FILE, or by MAINTENANCE at startup; the last
+// change wins.
+
+const defaultMaintenanceMessage = "Service is down for maintenance"
+
+type maintenanceState struct {
+	log       *logger
+	now       func() time.Time
+	readiness *readinessState
+
+	mu      sync.Mutex
+	enabled bool
+	message string
+	until   time.Time
+	since   time.Time
+	source  string
+}
+
+// newMaintenanceState keeps readiness failing while maintenance mode is
+// on.
+func newMaintenanceState(log *logger, now func() time.Time, readiness *readinessState) *maintenanceState {
+	return &maintenanceState{log: log, now: now, readiness: readiness}
+}
+
+// Enable turns maintenance mode on, or updates its message and end time.
+func (m *maintenanceState) Enable(source, message string, until time.Time) {
+	if message == "" {
+		message = defaultMaintenanceMessage
+	}
+	m.mu.Lock()
+	if !m.enabled {
+		m.since = m.now()
+	}
+	m.enabled, m.message, m.until, m.source = true, message, until, source
+	m.mu.Unlock()
+
+	m.readiness.Set("maintenance", "Maintenance: "+message)
+	m.log.warnf("server", "Maintenance mode on (%s): %s", source, message)
+}
+
+func (m *maintenanceState) Disable(source string) {
+	m.mu.Lock()
+	was := m.enabled
+	m.enabled, m.message, m.until, m.since, m.source = false, "", time.Time{}, time.Time{}, ""
+	m.mu.Unlock()
+
+	m.readiness.Clear("maintenance")
+	if was {
+		m.log.infof("server", "Maintenance mode off (%s)", source)
+	}
+}
+
+func (m *maintenanceState) snapshot() MaintenanceResp {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.enabled {
+		return MaintenanceResp{}
+	}
+	resp := MaintenanceResp{
+		Enabled: true,
+		Message: m.message,
+		Since:   m.since.UTC().Format(time.RFC3339),
+		Source:  m.source,
+	}
+	if !m.until.IsZero() {
+		resp.Until = m.until.UTC().Format(time.RFC3339)
+	}
+	return resp
+}
+
+// reject answers a request with 503 while maintenance mode is on and
+// reports whether it did. Retry-After points at the expected end.
+func (m *maintenanceState) reject(w http.ResponseWriter, r *http.Request) bool {
+	m.mu.Lock()
+	enabled, message, until := m.enabled, m.message, m.until
+	m.mu.Unlock()
+	if !enabled {
+		return false
+	}
+
+	// Only the default message can be translated; one set by an operator
+	// is sent as written.
+	loc := negotiateLocale(r)
+	resp := ErrorResp{Error: http.StatusText(http.StatusServiceUnavailable), Message: loc.message(message)}
+	if !until.IsZero() {
+		resp.Until = until.UTC().Format(time.RFC3339)
+		if left := until.Sub(m.now()); left > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(left.Seconds()))))
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Language", loc.tag)
+	w.WriteHeader(http.StatusServiceUnavailable)
+	json.NewEncoder(w).Encode(resp)
+	return true
+}
+
+// parseMaintenanceUntil reads an RFC 3339 end time; empty means unknown.
+func parseMaintenanceUntil(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid until %q: want an RFC 3339 time such as 2026-03-01T12:00:00Z", s)
+	}
+	return t, nil
+}
+
+// maintenanceFilePath resolves MAINTENANCE_FILE against DATA_DIR; empty
+// means no sentinel file.
+func maintenanceFilePath(cfg Config) string {
+	if cfg.MaintenanceFile == "" || filepath.IsAbs(cfg.MaintenanceFile) {
+		return cfg.MaintenanceFile
+	}
+	return filepath.Join(cfg.DataDir, cfg.MaintenanceFile)
+}
+
+// watchFile polls path until ctx is done. While the file exists
+// maintenance mode is on, with the file's first line as the message. A
+// ConfigMap key mounted there works too: removing the key removes the file.
+func (m *maintenanceState) watchFile(ctx context.Context, path string, interval time.Duration) {
+	var present bool
+	var message string
+	check := func() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if present && os.IsNotExist(err) {
+				present = false
+				m.Disable("file")
+			}
+			return
+		}
+		line, _, _ := strings.Cut(strings.TrimSpace(string(data)), "\n")
+		if present && line == message {
+			return
+		}
+		present, message = true, line
+		m.Enable("file", message, time.Time{})
+	}
+
+	check()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			check()
+		}
+	}
+}
+
+func (s *Server) maintenanceHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var req MaintenanceUpdate
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			writeJSONError(w, r, http.StatusBadRequest, "Invalid JSON body: "+err.Error())
+			return
+		}
+		until, err := parseMaintenanceUntil(req.Until)
+		if err != nil {
+			writeJSONError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		if req.Enabled {
+			s.maintenance.Enable("admin", req.Message, until)
+		} else {
+			s.maintenance.Disable("admin")
+		}
+	default:
+		writeJSONError(w, r, http.StatusMethodNotAllowed, "Only GET and PUT methods are allowed for this endpoint")
+		return
+	}
+	s.writeFormatted(w, r, responseFormats[0], http.StatusOK, s.maintenance.snapshot())
+}
@@ -0,0 +1,140 @@
+package infoservice
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func putMaintenance(t *testing.T, s *Server, body string) MaintenanceResp {
+	t.Helper()
+	w := httptest.NewRecorder()
+	s.maintenanceHandler(w, httptest.NewRequest(http.MethodPut, "/admin/maintenance", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT %s: status = %d, body %s", body, w.Code, w.Body)
+	}
+	var resp MaintenanceResp
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestMaintenance_AdminToggle(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := withClock(t, now, time.Minute)
+
+	resp := putMaintenance(t, s, `{"enabled": true, "message": "Moving to a new node", "until": "2026-03-01T12:30:00Z"}`)
+	want := MaintenanceResp{Enabled: true, Message: "Moving to a new node", Until: "2026-03-01T12:30:00Z", Since: "2026-03-01T12:00:00Z", Source: "admin"}
+	if resp != want {
+		t.Errorf("PUT = %+v, want %+v", resp, want)
+	}
+
+	w := serveGet(s.mainHandler, "/", nil)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("/ status = %d, want 503", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "1800" {
+		t.Errorf("Retry-After = %q, want 1800", got)
+	}
+	var body ErrorResp
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if body.Message != "Moving to a new node" || body.Until != "2026-03-01T12:30:00Z" {
+		t.Errorf("body = %+v", body)
+	}
+
+	if w := serveGet(s.readyHandler, "/ready", nil); w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "Maintenance: Moving") {
+		t.Errorf("/ready = %d %s, want 503 with the reason", w.Code, w.Body)
+	}
+	if w := serveGet(s.healthHandler, "/health", nil); w.Code != http.StatusOK {
+		t.Errorf("/health status = %d, want 200", w.Code)
+	}
+
+	if resp := putMaintenance(t, s, `{"enabled": false}`); resp.Enabled {
+		t.Errorf("PUT off = %+v", resp)
+	}
+	if w := serveGet(s.mainHandler, "/", nil); w.Code != http.StatusOK {
+		t.Errorf("/ after maintenance status = %d, want 200", w.Code)
+	}
+	if ready, _, _ := s.readiness.Status(); !ready {
+		t.Error("still not ready after maintenance")
+	}
+}
+
+func TestMaintenanceHandler_Errors(t *testing.T) {
+	s := newTestServer(t)
+	for _, tt := range []struct {
+		method, body string
+		want         int
+	}{
+		{http.MethodPut, `{"enabled": true, "until": "tomorrow"}`, http.StatusBadRequest},
+		{http.MethodPut, `{"enabled": true, "reason": "x"}`, http.StatusBadRequest},
+		{http.MethodPost, `{}`, http.StatusMethodNotAllowed},
+	} {
+		w := httptest.NewRecorder()
+		s.maintenanceHandler(w, httptest.NewRequest(tt.method, "/admin/maintenance", strings.NewReader(tt.body)))
+		if w.Code != tt.want {
+			t.Errorf("%s %s: status = %d, want %d", tt.method, tt.body, w.Code, tt.want)
+		}
+	}
+	if s.maintenance.snapshot().Enabled {
+		t.Error("a rejected request turned maintenance mode on")
+	}
+}
+
+func TestMaintenanceState_WatchFile(t *testing.T) {
+	m := newTestServer(t).maintenance
+	path := filepath.Join(t.TempDir(), "maintenance")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		m.watchFile(ctx, path, 5*time.Millisecond)
+		close(done)
+	}()
+	defer func() { cancel(); <-done }()
+
+	waitFor := func(what string, cond func(MaintenanceResp) bool) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for !cond(m.snapshot()) {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s, state %+v", what, m.snapshot())
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor("file on", func(s MaintenanceResp) bool {
+		return s.Enabled && s.Source == "file" && s.Message == defaultMaintenanceMessage
+	})
+
+	os.WriteFile(path, []byte("Restoring a backup\nignored\n"), 0o644)
+	waitFor("new message", func(s MaintenanceResp) bool { return s.Message == "Restoring a backup" })
+
+	os.Remove(path)
+	waitFor("file off", func(s MaintenanceResp) bool { return !s.Enabled })
+}
+
+func TestMaintenanceFilePath(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.DataDir = "/data"
+	for file, want := range map[string]string{
+		"maintenance":       "/data/maintenance",
+		"/etc/flags/paused": "/etc/flags/paused",
+		"":                  "",
+	} {
+		cfg.MaintenanceFile = file
+		if got := maintenanceFilePath(cfg); got != want {
+			t.Errorf("maintenanceFilePath(%q) = %q, want %q", file, got, want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package infoservice
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ==================== METRICS ====================
+
+// counterVec is a Prometheus counter with one label. Label values passed
+// to newCounterVec are exported as 0 until first counted, so rates work
+// from the first scrape.
+type counterVec struct {
+	name   string
+	help   string
+	label  string
+	mu     sync.Mutex
+	values map[string]uint64
+}
+
+func newCounterVec(name, help, label string, preset ...string) *counterVec {
+	c := &counterVec{name: name, help: help, label: label, values: map[string]uint64{}}
+	for _, v := range preset {
+		c.values[v] = 0
+	}
+	return c
+}
+
+func (c *counterVec) inc(value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[value]++
+}
+
+func (c *counterVec) get(value string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[value]
+}
+
+// write renders c in the Prometheus text exposition format. constLabels
+// go before the counter's own label in every series.
+func (c *counterVec) write(w io.Writer, constLabels string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	values := make([]string, 0, len(c.values))
+	for v := range c.values {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+	for _, v := range values {
+		fmt.Fprintf(w, "%s{%s%s=\"%s\"} %d\n", c.name, constLabels, c.label, labelEscaper.Replace(v), c.values[v])
+	}
+}
+
+// gaugeFunc is a Prometheus gauge read when scraped. value reports false
+// while there is nothing to measure, and the gauge is left out.
+type gaugeFunc struct {
+	name  string
+	help  string
+	value func() (float64, bool)
+}
+
+func (g *gaugeFunc) write(w io.Writer, constLabels string) {
+	v, ok := g.value()
+	if !ok {
+		return
+	}
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", g.name, g.help, g.name)
+	if labels := strings.TrimSuffix(constLabels, ","); labels != "" {
+		fmt.Fprintf(w, "%s{%s} %g\n", g.name, labels, v)
+	} else {
+		fmt.Fprintf(w, "%s %g\n", g.name, v)
+	}
+}
+
+// metric is anything /metrics can render.
+type metric interface {
+	write(w io.Writer, constLabels string)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+var logRecordsTotal = newCounterVec("devops_log_records_total", "Log records written, by level.", "level", logLevelNames...)
+
+// exportedMetrics lists what /metrics serves: the counters of the process
+// and the gauges of s.
+func (s *Server) exportedMetrics() []metric {
+	metrics := []metric{logRecordsTotal, panicsTotal, chaosFaultsTotal, shedRejectedTotal}
+	metrics = append(metrics, s.shedGauges()...)
+	metrics = append(metrics, timeSyncTotal)
+	return append(metrics, s.timeSyncGauges()...)
+}
+
+func (s *Server) metricsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, r, http.StatusMethodNotAllowed, "Only GET method is allowed for this endpoint")
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	// Every series carries the deployment identity, so an analysis can
+	// compare the canary with the stable stack.
+	labels := s.deployment.metricLabels()
+	writeDeploymentInfo(w, s.deployment)
+	for _, m := range s.exportedMetrics() {
+		m.write(w, labels)
+	}
+}
@@ -0,0 +1,79 @@
+package infoservice
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCounterVec_Write(t *testing.T) {
+	c := newCounterVec("test_total", "Things counted.", "kind", "b", "a")
+	c.inc("a")
+	c.inc("a")
+	c.inc(`odd"kind`)
+
+	var sb strings.Builder
+	c.write(&sb, `track="canary",`)
+	want := `# HELP test_total Things counted.
+# TYPE test_total counter
+test_total{track="canary",kind="a"} 2
+test_total{track="canary",kind="b"} 0
+test_total{track="canary",kind="odd\"kind"} 1
+`
+	if sb.String() != want {
+		t.Errorf("write =\n%s\nwant\n%s", sb.String(), want)
+	}
+}
+
+func TestGaugeFunc_Write(t *testing.T) {
+	value, ok := 2.5, true
+	g := &gaugeFunc{"test_gauge", "A level.", func() (float64, bool) { return value, ok }}
+
+	for _, tt := range []struct{ labels, want string }{
+		{`track="canary",`, "# HELP test_gauge A level.\n# TYPE test_gauge gauge\ntest_gauge{track=\"canary\"} 2.5\n"},
+		{"", "# HELP test_gauge A level.\n# TYPE test_gauge gauge\ntest_gauge 2.5\n"},
+	} {
+		var sb strings.Builder
+		g.write(&sb, tt.labels)
+		if sb.String() != tt.want {
+			t.Errorf("write(%q) = %q, want %q", tt.labels, sb.String(), tt.want)
+		}
+	}
+
+	ok = false
+	var sb strings.Builder
+	g.write(&sb, "")
+	if sb.Len() != 0 {
+		t.Errorf("without a value write = %q, want nothing", sb.String())
+	}
+}
+
+func TestMetricsHandler_LogRecords(t *testing.T) {
+	s := newTestServer(t)
+	before := logRecordsTotal.get("warn")
+	s.log.warnf("storage", "counted")
+	s.log.debugf("storage", "dropped, so not counted")
+
+	w := serveGet(s.metricsHandler, "/metrics", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	body := w.Body.String()
+	if want := fmt.Sprintf("devops_log_records_total{track=\"stable\",level=\"warn\"} %d\n", before+1); !strings.Contains(body, want) {
+		t.Errorf("body lacks %q:\n%s", want, body)
+	}
+	if !strings.Contains(body, `devops_log_records_total{track="stable",level="debug"}`) {
+		t.Errorf("debug series missing:\n%s", body)
+	}
+
+	w = httptest.NewRecorder()
+	s.metricsHandler(w, httptest.NewRequest(http.MethodPost, "/metrics", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: status = %d, want 405", w.Code)
+	}
+}
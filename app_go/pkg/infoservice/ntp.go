@@ -0,0 +1,207 @@
+package infoservice
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// ==================== TIME SYNC ====================
+
+// Logs and traces from pods whose clocks disagree are hard to line up.
+// With NTP_SERVER set, an SNTP client (RFC 4330) asks that server for the
+// time every NTP_INTERVAL and keeps the measured offset of the local
+// clock, shown in the runtime section and /metrics. /health reports
+// degraded while the offset is larger than NTP_MAX_SKEW. The clock itself
+// is never adjusted.
+
+// ntpEpoch is where NTP timestamps count from.
+var ntpEpoch = time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)
+
+const ntpPacketSize = 48
+
+var timeSyncTotal = newCounterVec("devops_time_sync_total", "SNTP queries, by result.", "result", "ok", "error")
+
+// timeSyncGauges report the last measurement of s.timeSync; there is none
+// while time sync is off or until the first query succeeds.
+func (s *Server) timeSyncGauges() []metric {
+	stat := func(field func(ClockSync) float64) func() (float64, bool) {
+		return func() (float64, bool) {
+			if s.timeSync == nil {
+				return 0, false
+			}
+			cs := s.timeSync.snapshot()
+			if cs.LastSync == "" {
+				return 0, false
+			}
+			return field(cs), true
+		}
+	}
+	return []metric{
+		&gaugeFunc{"devops_clock_offset_seconds", "Offset of the NTP server's clock from the local one; positive when the local clock is behind.", stat(func(cs ClockSync) float64 {
+			return cs.OffsetSeconds
+		})},
+		&gaugeFunc{"devops_clock_last_sync_timestamp_seconds", "Unix time of the last successful SNTP query.", stat(func(cs ClockSync) float64 {
+			t, _ := time.Parse(time.RFC3339, cs.LastSync)
+			return float64(t.Unix())
+		})},
+	}
+}
+
+type timeSyncer struct {
+	server   string
+	interval time.Duration
+	timeout  time.Duration
+	maxSkew  time.Duration
+	now      func() time.Time
+	log      *logger
+
+	mu       sync.Mutex
+	offset   time.Duration
+	delay    time.Duration
+	lastSync time.Time
+	lastErr  error
+}
+
+// newTimeSyncer measures the clock now reads, normally the Server's, and
+// logs failed queries to log.
+func newTimeSyncer(cfg Config, now func() time.Time, log *logger) *timeSyncer {
+	return &timeSyncer{
+		server:   ntpAddress(cfg.NTPServer),
+		interval: cfg.NTPInterval,
+		timeout:  cfg.NTPTimeout,
+		maxSkew:  cfg.NTPMaxSkew,
+		now:      now,
+		log:      log,
+	}
+}
+
+// ntpAddress adds the NTP port to a server given without one.
+func ntpAddress(server string) string {
+	if _, _, err := net.SplitHostPort(server); err == nil {
+		return server
+	}
+	return net.JoinHostPort(server, "123")
+}
+
+// run measures the clock right away and then every interval until ctx is
+// done.
+func (t *timeSyncer) run(ctx context.Context) {
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+	for {
+		if err := t.sync(ctx); err != nil && ctx.Err() == nil {
+			t.log.warnf("server", "Time sync with %s failed: %v", t.server, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sync queries the server once. A failure keeps the last measurement.
+func (t *timeSyncer) sync(ctx context.Context) error {
+	offset, delay, err := ntpQuery(ctx, t.server, t.timeout, t.now)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err != nil {
+		t.lastErr = err
+		timeSyncTotal.inc("error")
+		return err
+	}
+	t.offset, t.delay, t.lastSync, t.lastErr = offset, delay, t.now(), nil
+	timeSyncTotal.inc("ok")
+	return nil
+}
+
+func (t *timeSyncer) snapshot() ClockSync {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := ClockSync{
+		Server:        t.server,
+		OffsetSeconds: t.offset.Seconds(),
+		DelaySeconds:  t.delay.Seconds(),
+	}
+	if !t.lastSync.IsZero() {
+		s.LastSync = t.lastSync.UTC().Format(time.RFC3339)
+	}
+	if t.lastErr != nil {
+		s.LastError = t.lastErr.Error()
+	}
+	return s
+}
+
+// skewed reports whether the last measured offset, either way, is above
+// maxSkew.
+func (t *timeSyncer) skewed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return !t.lastSync.IsZero() && (t.offset > t.maxSkew || t.offset < -t.maxSkew)
+}
+
+// ntpQuery asks server for the time once and returns the offset of its
+// clock from now and the round-trip delay.
+func ntpQuery(ctx context.Context, server string, timeout time.Duration, now func() time.Time) (offset, delay time.Duration, err error) {
+	d := net.Dialer{Timeout: timeout}
+	conn, err := d.DialContext(ctx, "udp", server)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	req := make([]byte, ntpPacketSize)
+	req[0] = 0x23 // leap indicator 0, version 4, mode 3 (client)
+	t1 := now()
+	putNTPTime(req[40:], t1)
+	if _, err := conn.Write(req); err != nil {
+		return 0, 0, err
+	}
+
+	resp := make([]byte, 512)
+	n, err := conn.Read(resp)
+	t4 := now()
+	if err != nil {
+		return 0, 0, err
+	}
+	switch {
+	case n < ntpPacketSize:
+		return 0, 0, fmt.Errorf("short reply of %d bytes", n)
+	case resp[0]&0x07 != 4:
+		return 0, 0, errors.New("reply is not from a server")
+	case resp[1] == 0:
+		// Kiss-o'-Death: the server tells us to go away, with a reason code.
+		return 0, 0, fmt.Errorf("server refused the query: %s", bytes.TrimRight(resp[12:16], "\x00"))
+	case resp[0]>>6 == 3:
+		return 0, 0, errors.New("server clock is not synchronized")
+	case !bytes.Equal(resp[24:32], req[40:48]):
+		return 0, 0, errors.New("reply does not match the query")
+	}
+
+	t2, t3 := ntpTime(resp[32:40]), ntpTime(resp[40:48])
+	offset = (t2.Sub(t1) + t3.Sub(t4)) / 2
+	delay = t4.Sub(t1) - t3.Sub(t2)
+	return offset, delay, nil
+}
+
+// putNTPTime writes t as a 64-bit NTP timestamp: seconds since ntpEpoch
+// and a 32-bit binary fraction. Era 0 ends in 2036.
+func putNTPTime(b []byte, t time.Time) {
+	d := t.Sub(ntpEpoch)
+	sec := uint64(d / time.Second)
+	frac := uint64(d%time.Second) << 32 / uint64(time.Second)
+	binary.BigEndian.PutUint64(b, sec<<32|frac)
+}
+
+func ntpTime(b []byte) time.Time {
+	v := binary.BigEndian.Uint64(b)
+	sec, frac := v>>32, v&0xffffffff
+	return ntpEpoch.Add(time.Duration(sec)*time.Second + time.Duration(frac*uint64(time.Second)>>32))
+}
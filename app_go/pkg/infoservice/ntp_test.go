@@ -0,0 +1,229 @@
+package infoservice
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startNTPStandIn answers SNTP queries on a local UDP port with a clock
+// skew ahead of the real one. reply may rewrite an answer, or drop it by
+// returning false.
+func startNTPStandIn(t *testing.T, skew time.Duration, reply func(resp []byte) bool) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if n < ntpPacketSize {
+				continue
+			}
+			resp := make([]byte, ntpPacketSize)
+			resp[0] = 0x24 // version 4, mode 4 (server)
+			resp[1] = 1    // stratum 1
+			copy(resp[24:32], buf[40:48])
+			putNTPTime(resp[32:], time.Now().Add(skew))
+			putNTPTime(resp[40:], time.Now().Add(skew))
+			if reply != nil && !reply(resp) {
+				continue
+			}
+			conn.WriteTo(resp, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+// withTimeSync turns time sync on for s against server.
+func withTimeSync(s *Server, server string, maxSkew time.Duration) *timeSyncer {
+	cfg := DefaultConfig()
+	cfg.NTPServer, cfg.NTPTimeout, cfg.NTPMaxSkew = server, time.Second, maxSkew
+	s.timeSync = newTimeSyncer(cfg, s.now, s.log)
+	return s.timeSync
+}
+
+func TestNTPTime_RoundTrip(t *testing.T) {
+	b := make([]byte, 8)
+	putNTPTime(b, time.Unix(0, 0))
+	if got := string(b[:4]); got != "\x83\xaa\x7e\x80" { // 2208988800 seconds
+		t.Errorf("Unix epoch seconds = % x", b[:4])
+	}
+	for _, want := range []time.Time{
+		ntpEpoch,
+		time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 1, 12, 0, 0, 123456789, time.UTC),
+	} {
+		putNTPTime(b, want)
+		if d := ntpTime(b).Sub(want); d < -time.Nanosecond || d > time.Nanosecond {
+			t.Errorf("round trip of %v is off by %v", want, d)
+		}
+	}
+}
+
+func TestNTPAddress(t *testing.T) {
+	for in, want := range map[string]string{
+		"pool.ntp.org":      "pool.ntp.org:123",
+		"pool.ntp.org:1123": "pool.ntp.org:1123",
+		"127.0.0.1":         "127.0.0.1:123",
+		"::1":               "[::1]:123",
+		"[::1]:9123":        "[::1]:9123",
+	} {
+		if got := ntpAddress(in); got != want {
+			t.Errorf("ntpAddress(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNTPQuery_Offset(t *testing.T) {
+	for _, skew := range []time.Duration{0, 2 * time.Second, -3*time.Second - 250*time.Millisecond} {
+		server := startNTPStandIn(t, skew, nil)
+		offset, delay, err := ntpQuery(context.Background(), server, time.Second, time.Now)
+		if err != nil {
+			t.Fatalf("skew %v: %v", skew, err)
+		}
+		if d := offset - skew; d < -50*time.Millisecond || d > 50*time.Millisecond {
+			t.Errorf("skew %v: offset = %v", skew, offset)
+		}
+		if delay < 0 || delay > 100*time.Millisecond {
+			t.Errorf("skew %v: delay = %v", skew, delay)
+		}
+	}
+}
+
+func TestNTPQuery_BadReplies(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		reply func([]byte) bool
+		want  string
+	}{
+		{"kiss of death", func(b []byte) bool { b[1] = 0; copy(b[12:16], "RATE"); return true }, "refused the query: RATE"},
+		{"client mode", func(b []byte) bool { b[0] = 0x23; return true }, "not from a server"},
+		{"unsynchronized", func(b []byte) bool { b[0] |= 0xc0; return true }, "not synchronized"},
+		{"wrong originate", func(b []byte) bool { b[24]++; return true }, "does not match"},
+		{"no answer", func([]byte) bool { return false }, "timeout"},
+	} {
+		server := startNTPStandIn(t, 0, tt.reply)
+		_, _, err := ntpQuery(context.Background(), server, 100*time.Millisecond, time.Now)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestTimeSyncer_Sync(t *testing.T) {
+	srv := newTestServer(t)
+	sync := withTimeSync(srv, startNTPStandIn(t, 0, func([]byte) bool { return false }), time.Second)
+	sync.timeout = 50 * time.Millisecond
+	offsetGauge := srv.timeSyncGauges()[0].(*gaugeFunc)
+	okBefore, errorsBefore := timeSyncTotal.get("ok"), timeSyncTotal.get("error")
+
+	if err := sync.sync(context.Background()); err == nil {
+		t.Fatal("sync against a silent server succeeded")
+	}
+	if s := sync.snapshot(); s.LastSync != "" || s.LastError == "" {
+		t.Errorf("after a failure snapshot = %+v", s)
+	}
+	if _, ok := offsetGauge.value(); ok {
+		t.Error("offset gauge reported before the first successful sync")
+	}
+
+	sync.server = startNTPStandIn(t, 1500*time.Millisecond, nil)
+	if err := sync.sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	s := sync.snapshot()
+	if s.LastSync == "" || s.LastError != "" || s.OffsetSeconds < 1.4 || s.OffsetSeconds > 1.6 {
+		t.Errorf("snapshot = %+v", s)
+	}
+	if got, ok := offsetGauge.value(); !ok || got != s.OffsetSeconds {
+		t.Errorf("offset gauge = %v %v", got, ok)
+	}
+	if timeSyncTotal.get("ok") != okBefore+1 || timeSyncTotal.get("error") != errorsBefore+1 {
+		t.Error("devops_time_sync_total not counted")
+	}
+}
+
+func TestTimeSyncer_Run(t *testing.T) {
+	sync := withTimeSync(newTestServer(t), startNTPStandIn(t, 0, nil), time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		sync.run(ctx)
+		close(done)
+	}()
+	defer func() { cancel(); <-done }()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for sync.snapshot().LastSync == "" {
+		if time.Now().After(deadline) {
+			t.Fatal("run did not sync")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestHealthHandler_ClockSkew(t *testing.T) {
+	for _, tt := range []struct {
+		skew       time.Duration
+		wantStatus string
+	}{
+		{0, "healthy"},
+		{3 * time.Second, "degraded"},
+		{-3 * time.Second, "degraded"},
+	} {
+		server := startNTPStandIn(t, tt.skew, nil)
+		s := newTestServer(t)
+		if err := withTimeSync(s, server, time.Second).sync(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		w := serveGet(s.healthHandler, "/health", nil)
+		if w.Code != http.StatusOK {
+			t.Errorf("skew %v: status code = %d, want 200", tt.skew, w.Code)
+		}
+		var health HealthResp
+		json.Unmarshal(w.Body.Bytes(), &health)
+		if health.Status != tt.wantStatus {
+			t.Errorf("skew %v: status = %q, want %q", tt.skew, health.Status, tt.wantStatus)
+		}
+		if degraded := tt.wantStatus == "degraded"; degraded != (len(health.Reasons) == 1 && strings.Contains(health.Reasons[0], server)) {
+			t.Errorf("skew %v: reasons = %q", tt.skew, health.Reasons)
+		}
+	}
+}
+
+func TestMainHandler_ClockSync(t *testing.T) {
+	s := newTestServer(t)
+	if w := serveGet(s.mainHandler, "/?sections=runtime", nil); strings.Contains(w.Body.String(), "clock_sync") {
+		t.Errorf("clock_sync shown while time sync is off: %s", w.Body)
+	}
+
+	server := startNTPStandIn(t, 0, nil)
+	withTimeSync(s, server, time.Second).sync(context.Background())
+	w := serveGet(s.mainHandler, "/", nil)
+	var info ServiceInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &info); err != nil {
+		t.Fatal(err)
+	}
+	if cs := info.Runtime.ClockSync; cs == nil || cs.Server != server || cs.LastSync == "" {
+		t.Errorf("clock_sync = %+v", cs)
+	}
+
+	w = serveGet(s.metricsHandler, "/metrics", nil)
+	for _, want := range []string{"devops_clock_offset_seconds{", "devops_clock_last_sync_timestamp_seconds{", `devops_time_sync_total{`} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("metrics lack %s", want)
+		}
+	}
+}
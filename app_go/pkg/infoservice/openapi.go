@@ -0,0 +1,217 @@
+package infoservice
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ==================== OPENAPI ====================
+
+// openAPIDocument builds an OpenAPI 3.1 description of routes. Response
+// schemas are derived from the Go types: json tags name the properties,
+// every non-volatile property is required, and a format tag becomes the
+// JSON Schema format.
+func openAPIDocument(routes []apiRoute) map[string]interface{} {
+	schemas := map[string]interface{}{}
+	errorRef := schemaRef(reflect.TypeOf(ErrorResp{}), schemas)
+
+	paths := map[string]interface{}{}
+	for _, rt := range routes {
+		responses := map[string]interface{}{}
+
+		var okSchema map[string]interface{}
+		if rt.Response != nil {
+			okSchema = schemaRef(reflect.TypeOf(rt.Response), schemas)
+		} else {
+			okSchema = map[string]interface{}{"type": "object"}
+		}
+		content := map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": okSchema},
+		}
+		if rt.Streaming {
+			content = map[string]interface{}{
+				"text/event-stream": map[string]interface{}{"schema": map[string]interface{}{"type": "string"}},
+			}
+		}
+		if rt.ContentType != "" {
+			content = map[string]interface{}{
+				rt.ContentType: map[string]interface{}{"schema": map[string]interface{}{"type": "string"}},
+			}
+		}
+		for _, ct := range rt.AltContentTypes {
+			content[ct] = map[string]interface{}{"schema": map[string]interface{}{"type": "string"}}
+		}
+		if rt.WebSocket {
+			responses["101"] = map[string]interface{}{"description": "Switching Protocols to WebSocket"}
+		} else {
+			responses["200"] = map[string]interface{}{
+				"description": rt.Summary,
+				"content":     content,
+			}
+		}
+
+		for _, status := range rt.Errors {
+			responses[strconv.Itoa(status)] = map[string]interface{}{
+				"description": http.StatusText(status),
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": errorRef},
+				},
+			}
+		}
+
+		operation := map[string]interface{}{
+			"summary":     rt.Summary,
+			"operationId": operationID(rt),
+			"responses":   responses,
+		}
+		if rt.Request != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": schemaRef(reflect.TypeOf(rt.Request), schemas)},
+				},
+			}
+		}
+		if rt.Admin {
+			operation["security"] = []interface{}{map[string]interface{}{"adminToken": []interface{}{}}}
+		}
+		if len(rt.Params) > 0 {
+			var params []interface{}
+			for _, p := range rt.Params {
+				schema := map[string]interface{}{"type": "string"}
+				if len(p.Enum) > 0 {
+					schema["enum"] = p.Enum
+				}
+				params = append(params, map[string]interface{}{
+					"name":        p.Name,
+					"in":          "query",
+					"required":    false,
+					"description": p.Description,
+					"schema":      schema,
+				})
+			}
+			operation["parameters"] = params
+		}
+
+		item, _ := paths[rt.Path].(map[string]interface{})
+		if item == nil {
+			item = map[string]interface{}{}
+			paths[rt.Path] = item
+		}
+		item[strings.ToLower(rt.Method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.1.0",
+		"info": map[string]interface{}{
+			"title":       "devops-info-service",
+			"version":     version,
+			"description": "DevOps course info service",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"adminToken": map[string]interface{}{"type": "http", "scheme": "bearer", "description": "ADMIN_TOKEN"},
+			},
+		},
+	}
+}
+
+// operationID turns "GET /health" into "getHealth" and "GET /" into "getRoot".
+func operationID(rt apiRoute) string {
+	id := strings.ToLower(rt.Method)
+	parts := strings.FieldsFunc(rt.Path, func(r rune) bool {
+		return r == '/' || r == '.' || r == '-' || r == '_'
+	})
+	if len(parts) == 0 {
+		parts = []string{"root"}
+	}
+	for _, p := range parts {
+		id += strings.ToUpper(p[:1]) + p[1:]
+	}
+	return id
+}
+
+// schemaRef registers named struct types under components/schemas and
+// returns a $ref to them; other types are described inline.
+func schemaRef(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if _, ok := schemas[t.Name()]; !ok {
+			schemas[t.Name()] = nil // placeholder, stops recursion on cycles
+			schemas[t.Name()] = structSchema(t, schemas)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaRef(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaRef(t.Elem(), schemas)}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	}
+	return map[string]interface{}{}
+}
+
+func structSchema(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	properties := map[string]interface{}{}
+	var required []string
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		tag := strings.Split(f.Tag.Get("json"), ",")
+		name := tag[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		prop := schemaRef(f.Type, schemas)
+		if format := f.Tag.Get("format"); format != "" {
+			prop["format"] = format
+		}
+		properties[name] = prop
+
+		// Volatile fields can be left out with ?volatile=false.
+		omitempty := len(tag) > 1 && tag[1] == "omitempty"
+		if !omitempty && f.Tag.Get("volatile") != "true" {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (s *Server) openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, r, http.StatusMethodNotAllowed, "Only GET method is allowed for this endpoint")
+		return
+	}
+	s.writeCacheable(w, r, responseFormats[0], openAPIDocument(s.routes()), "no-cache")
+}
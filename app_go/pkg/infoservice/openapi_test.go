@@ -0,0 +1,209 @@
+package infoservice
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// declaredSchema returns the JSON schema the document declares for the
+// given route and status.
+func declaredSchema(t *testing.T, doc map[string]interface{}, path, method string, status int) interface{} {
+	t.Helper()
+	paths, _ := doc["paths"].(map[string]interface{})
+	item, _ := paths[path].(map[string]interface{})
+	op, _ := item[strings.ToLower(method)].(map[string]interface{})
+	responses, _ := op["responses"].(map[string]interface{})
+	resp, _ := responses[strconv.Itoa(status)].(map[string]interface{})
+	content, _ := resp["content"].(map[string]interface{})
+	media, _ := content["application/json"].(map[string]interface{})
+	schema, ok := media["schema"]
+	if !ok {
+		t.Fatalf("no JSON schema declared for %s %s %d", method, path, status)
+	}
+	return schema
+}
+
+// checkResponse sends a request to h and validates the JSON body against
+// the schema declared for the status it got back.
+func checkResponse(t *testing.T, h http.Handler, doc map[string]interface{}, route apiRoute, req *http.Request, wantStatus int) []string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != wantStatus {
+		t.Fatalf("%s %s: status = %d, want %d", req.Method, req.URL, w.Code, wantStatus)
+	}
+
+	var body interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("%s %s: invalid JSON: %v", req.Method, req.URL, err)
+	}
+	schema := declaredSchema(t, doc, route.Path, route.Method, wantStatus)
+	return schemaValidator{root: doc}.validate(schema, body, "$")
+}
+
+func TestOpenAPI_Served(t *testing.T) {
+	w := httptest.NewRecorder()
+	newTestServer(t).Handler().ServeHTTP(w, httptest.NewRequest("GET", "/openapi.json", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if doc["openapi"] != "3.1.0" {
+		t.Errorf("openapi = %v, want 3.1.0", doc["openapi"])
+	}
+
+	paths, _ := doc["paths"].(map[string]interface{})
+	for _, rt := range newTestServer(t).routes() {
+		item, _ := paths[rt.Path].(map[string]interface{})
+		if _, ok := item[strings.ToLower(rt.Method)]; !ok {
+			t.Errorf("route %s %s missing from the document", rt.Method, rt.Path)
+		}
+	}
+
+	schemas := doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	for _, name := range []string{"ServiceInfo", "HealthResp", "ErrorResp", "Runtime", "Endpoint"} {
+		if _, ok := schemas[name]; !ok {
+			t.Errorf("components.schemas missing %s", name)
+		}
+	}
+}
+
+func TestOpenAPI_HandlersMatchSchemas(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AdminToken = "test-token"
+	cfg.LogBufferSize = 10
+	s := newTestServer(t, WithConfig(cfg))
+	t.Cleanup(s.logTail.Close)
+	withVisitStore(t, s)
+	withChaos(s)
+	doc := toJSONValue(t, openAPIDocument(s.routes()))
+	h := s.Handler()
+	authorize := func(req *http.Request) *http.Request {
+		req.Header.Set("Authorization", "Bearer test-token")
+		return req
+	}
+
+	for _, rt := range s.routes() {
+		t.Run(rt.Method+" "+rt.Path, func(t *testing.T) {
+			// Streams never end on their own; events_test.go and ws_test.go
+			// cover them, and metrics_test.go the plain text /metrics.
+			if !rt.Streaming && !rt.WebSocket && rt.ContentType == "" {
+				var body io.Reader
+				if rt.Request != nil {
+					b, _ := json.Marshal(rt.Request)
+					body = bytes.NewReader(b)
+				}
+				req := authorize(httptest.NewRequest(rt.Method, rt.Path, body))
+				if errs := checkResponse(t, h, doc, rt, req, http.StatusOK); len(errs) > 0 {
+					t.Errorf("200 response does not match its schema:\n%s", strings.Join(errs, "\n"))
+				}
+			}
+
+			if hasStatus(rt.Errors, http.StatusMethodNotAllowed) {
+				req := authorize(httptest.NewRequest(http.MethodPost, rt.Path, nil))
+				if errs := checkResponse(t, h, doc, rt, req, http.StatusMethodNotAllowed); len(errs) > 0 {
+					t.Errorf("405 response does not match its schema:\n%s", strings.Join(errs, "\n"))
+				}
+			}
+			if hasStatus(rt.Errors, http.StatusBadRequest) && hasParam(rt.Params, "volatile") {
+				req := httptest.NewRequest(rt.Method, rt.Path+"?volatile=maybe", nil)
+				if errs := checkResponse(t, h, doc, rt, req, http.StatusBadRequest); len(errs) > 0 {
+					t.Errorf("400 response does not match its schema:\n%s", strings.Join(errs, "\n"))
+				}
+			}
+			if hasStatus(rt.Errors, http.StatusUnauthorized) {
+				req := httptest.NewRequest(rt.Method, rt.Path, nil)
+				if errs := checkResponse(t, h, doc, rt, req, http.StatusUnauthorized); len(errs) > 0 {
+					t.Errorf("401 response does not match its schema:\n%s", strings.Join(errs, "\n"))
+				}
+			}
+			if hasStatus(rt.Errors, http.StatusNotAcceptable) {
+				req := httptest.NewRequest(rt.Method, rt.Path, nil)
+				req.Header.Set("Accept", "application/xml")
+				if errs := checkResponse(t, h, doc, rt, req, http.StatusNotAcceptable); len(errs) > 0 {
+					t.Errorf("406 response does not match its schema:\n%s", strings.Join(errs, "\n"))
+				}
+			}
+		})
+	}
+}
+
+func TestOpenAPI_VolatileFieldsOptional(t *testing.T) {
+	s := newTestServer(t)
+	withVisitStore(t, s)
+	doc := toJSONValue(t, openAPIDocument(s.routes()))
+	h := s.Handler()
+
+	for _, rt := range s.routes() {
+		if rt.Response == nil || !hasParam(rt.Params, "volatile") {
+			continue
+		}
+		req := httptest.NewRequest(rt.Method, rt.Path+"?volatile=false", nil)
+		if errs := checkResponse(t, h, doc, rt, req, http.StatusOK); len(errs) > 0 {
+			t.Errorf("%s without volatile fields does not match:\n%s", rt.Path, strings.Join(errs, "\n"))
+		}
+	}
+}
+
+func TestOpenAPI_DetectsDrift(t *testing.T) {
+	// A handler that returns something other than what its route declares
+	// must be caught by the same check.
+	drifted := apiRoute{
+		Path:     "/health",
+		Method:   http.MethodGet,
+		Summary:  "Health check",
+		Response: HealthResp{},
+		Handler: func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `{"status": "healthy", "uptime_seconds": "ten", "extra": 1}`)
+		},
+	}
+	doc := toJSONValue(t, openAPIDocument([]apiRoute{drifted}))
+
+	errs := checkResponse(t, drifted.Handler, doc, drifted, httptest.NewRequest("GET", "/health", nil), http.StatusOK)
+	joined := strings.Join(errs, "\n")
+	if !strings.Contains(joined, "uptime_seconds: expected type integer") || !strings.Contains(joined, "extra: unexpected property") {
+		t.Errorf("drift not detected, errors: %v", errs)
+	}
+}
+
+func TestOperationID(t *testing.T) {
+	testCases := map[string]string{
+		"/":             "getRoot",
+		"/health":       "getHealth",
+		"/openapi.json": "getOpenapiJson",
+	}
+	for path, want := range testCases {
+		if got := operationID(apiRoute{Path: path, Method: "GET"}); got != want {
+			t.Errorf("operationID(%s) = %s, want %s", path, got, want)
+		}
+	}
+}
+
+func hasStatus(list []int, status int) bool {
+	for _, s := range list {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+func hasParam(params []apiParam, name string) bool {
+	for _, p := range params {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
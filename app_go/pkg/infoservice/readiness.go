@@ -0,0 +1,76 @@
+package infoservice
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ==================== READINESS ====================
+
+// readinessState tracks the reasons the service should not get traffic; it
+// is ready while there are none. Liveness (/health) is separate: a pod that
+// is shutting down is still alive, just not ready.
+type readinessState struct {
+	mu      sync.Mutex
+	reasons map[string]string
+	changed chan struct{} // closed and replaced on every change
+}
+
+func newReadiness() *readinessState {
+	return &readinessState{reasons: map[string]string{}, changed: make(chan struct{})}
+}
+
+// Set marks the service not ready for reason, with a message for humans.
+func (s *readinessState) Set(reason, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if old, ok := s.reasons[reason]; ok && old == message {
+		return
+	}
+	s.reasons[reason] = message
+	s.notify()
+}
+
+// Clear removes reason; the service is ready again once no reasons remain.
+func (s *readinessState) Clear(reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.reasons[reason]; !ok {
+		return
+	}
+	delete(s.reasons, reason)
+	s.notify()
+}
+
+func (s *readinessState) notify() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// Status returns whether the service is ready, the messages explaining why
+// not in a stable order, and a channel that is closed on the next change.
+func (s *readinessState) Status() (bool, []string, <-chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	messages := make([]string, 0, len(s.reasons))
+	for _, m := range s.reasons {
+		messages = append(messages, m)
+	}
+	sort.Strings(messages)
+	return len(messages) == 0, messages, s.changed
+}
+
+func (s *Server) readyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, r, http.StatusMethodNotAllowed, "Only GET method is allowed for this endpoint")
+		return
+	}
+	ready, reasons, _ := s.readiness.Status()
+	if !ready {
+		writeJSONError(w, r, http.StatusServiceUnavailable, strings.Join(reasons, "; "))
+		return
+	}
+	s.writeFormatted(w, r, responseFormats[0], http.StatusOK, ReadyResp{Status: "ready"})
+}
@@ -0,0 +1,74 @@
+package infoservice
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestReadiness_SetAndClear(t *testing.T) {
+	s := newReadiness()
+
+	ready, reasons, changed := s.Status()
+	if !ready || len(reasons) != 0 {
+		t.Fatalf("new state = (%v, %v), want ready", ready, reasons)
+	}
+
+	s.Set("shutdown", "Server is shutting down")
+	select {
+	case <-changed:
+	default:
+		t.Fatal("Set did not signal a change")
+	}
+
+	s.Set("b", "second reason")
+	if ready, reasons, _ = s.Status(); ready || len(reasons) != 2 || reasons[0] != "Server is shutting down" {
+		t.Errorf("status = (%v, %v), want not ready with both reasons sorted", ready, reasons)
+	}
+
+	s.Clear("shutdown")
+	s.Clear("b")
+	if ready, _, _ = s.Status(); !ready {
+		t.Error("still not ready after clearing every reason")
+	}
+}
+
+func TestReadiness_RepeatedSetIsNotAChange(t *testing.T) {
+	s := newReadiness()
+	s.Set("x", "reason")
+	_, _, changed := s.Status()
+
+	s.Set("x", "reason")
+	s.Clear("unknown")
+	select {
+	case <-changed:
+		t.Error("no-op updates signalled a change")
+	default:
+	}
+}
+
+func TestReadyHandler(t *testing.T) {
+	srv := newTestServer(t)
+	s := srv.readiness
+
+	w := serveGet(srv.readyHandler, "/ready", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+
+	s.Set("shutdown", "Server is shutting down")
+	w = serveGet(srv.readyHandler, "/ready", nil)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", w.Code)
+	}
+	var resp ErrorResp
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Message != "Server is shutting down" {
+		t.Errorf("message = %q, want the readiness reason", resp.Message)
+	}
+
+	// Liveness is not affected.
+	if w := serveGet(srv.healthHandler, "/health", nil); w.Code != http.StatusOK {
+		t.Errorf("/health status = %d while not ready, want 200", w.Code)
+	}
+}
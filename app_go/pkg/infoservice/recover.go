@@ -0,0 +1,115 @@
+package infoservice
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"strings"
+)
+
+// ==================== PANIC RECOVERY ====================
+
+var panicsTotal = newCounterVec("devops_panics_total", "Handler panics recovered, by route.", "route")
+
+// panicStackFrames caps the stack sent to clients in debug mode; the log
+// always gets all of it.
+const panicStackFrames = 10
+
+// recoverPanics turns a panic in the handler for route into a 500 JSON
+// error carrying the request ID, instead of a dropped connection and a raw
+// stack on stderr. While http debug logging is on, the response also holds
+// the top of the stack.
+func (s *Server) recoverPanics(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pw := &panicWriter{ResponseWriter: w}
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				// A deliberate abort; net/http handles it quietly.
+				panic(v)
+			}
+			stack := debug.Stack()
+			id := requestIDFrom(r.Context())
+			panicsTotal.inc(route)
+			s.log.log(levelError, "http", map[string]interface{}{
+				"request_id": id,
+				"stack":      strings.TrimSpace(string(stack)),
+			}, "Panic serving %s %s: %v", r.Method, r.URL.Path, v)
+
+			if pw.wrote {
+				// Part of the response is already out; a 500 cannot follow
+				// it, so cut the connection and let the client see that.
+				panic(http.ErrAbortHandler)
+			}
+			resp := ErrorResp{
+				Error:     http.StatusText(http.StatusInternalServerError),
+				Message:   negotiateLocale(r).message("The server hit an unexpected error, quote the request ID when reporting it"),
+				RequestID: id,
+			}
+			if s.log.levels.enabled(levelDebug, "http") {
+				resp.Message = fmt.Sprintf("panic: %v", v)
+				resp.Stack = trimStack(stack, panicStackFrames)
+			}
+			// Drop what the handler set for the response it did not send.
+			for _, h := range []string{"Content-Length", "ETag", "Last-Modified", "Cache-Control"} {
+				w.Header().Del(h)
+			}
+			s.writeFormatted(w, r, responseFormats[0], http.StatusInternalServerError, resp)
+		}()
+		next(pw, r)
+	}
+}
+
+// trimStack turns debug.Stack output into one "function file:line" entry
+// per frame, starting at the frame that panicked.
+func trimStack(stack []byte, max int) []string {
+	lines := strings.Split(strings.TrimSpace(string(stack)), "\n")
+	var frames []string
+	for i := 1; i+1 < len(lines); i += 2 {
+		fn := lines[i]
+		loc := strings.TrimSpace(lines[i+1])
+		if j := strings.LastIndex(loc, " +0x"); j >= 0 {
+			loc = loc[:j]
+		}
+		if strings.HasPrefix(fn, "panic(") {
+			frames = frames[:0]
+			continue
+		}
+		frames = append(frames, fn+" "+loc)
+	}
+	if len(frames) > max {
+		frames = frames[:max]
+	}
+	return frames
+}
+
+// panicWriter notes whether the handler has started its response.
+type panicWriter struct {
+	http.ResponseWriter
+	wrote bool
+}
+
+func (w *panicWriter) WriteHeader(code int) {
+	w.wrote = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *panicWriter) Write(b []byte) (int, error) {
+	w.wrote = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *panicWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.wrote = true
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *panicWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,92 @@
+package infoservice
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func panicky(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("ETag", `"stale"`)
+	panic("nil map write")
+}
+
+func servePanic(t *testing.T, h http.Handler) (*httptest.ResponseRecorder, ErrorResp) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/boom", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", w.Code)
+	}
+	var resp ErrorResp
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	return w, resp
+}
+
+func TestRecoverPanics(t *testing.T) {
+	s := newTestServer(t)
+	var records []logRecord
+	defer s.log.addSink(func(rec logRecord) { records = append(records, rec) })()
+	before := panicsTotal.get("/boom")
+
+	w, resp := servePanic(t, withRequestID(s.recoverPanics("/boom", panicky)))
+
+	if resp.RequestID == "" || resp.RequestID != w.Header().Get(requestIDHeader) {
+		t.Errorf("request_id = %q, header %q", resp.RequestID, w.Header().Get(requestIDHeader))
+	}
+	if resp.Stack != nil || strings.Contains(resp.Message, "nil map") {
+		t.Errorf("panic details leaked outside debug mode: %+v", resp)
+	}
+	if w.Header().Get("ETag") != "" {
+		t.Error("500 kept the handler's ETag")
+	}
+	if got := panicsTotal.get("/boom"); got != before+1 {
+		t.Errorf("devops_panics_total{route=/boom} = %d, want %d", got, before+1)
+	}
+
+	if len(records) != 1 || records[0].Level != "error" {
+		t.Fatalf("records = %+v, want one error", records)
+	}
+	stack, _ := records[0].Fields["stack"].(string)
+	if records[0].Fields["request_id"] != resp.RequestID || !strings.Contains(stack, "panicky") {
+		t.Errorf("fields = %v, want the request ID and a stack through panicky", records[0].Fields)
+	}
+}
+
+func TestRecoverPanics_DebugStack(t *testing.T) {
+	s := newTestServer(t)
+	debug := levelDebug
+	s.log.levels.set("http", &debug)
+
+	_, resp := servePanic(t, s.recoverPanics("/boom", panicky))
+	if resp.Message != "panic: nil map write" {
+		t.Errorf("message = %q", resp.Message)
+	}
+	if len(resp.Stack) == 0 || len(resp.Stack) > panicStackFrames {
+		t.Fatalf("stack has %d frames, want 1..%d", len(resp.Stack), panicStackFrames)
+	}
+	if !strings.HasPrefix(resp.Stack[0], "devops-info-service/pkg/infoservice.panicky(") {
+		t.Errorf("stack starts at %q, want the panicking function", resp.Stack[0])
+	}
+	if strings.Contains(resp.Stack[0], "+0x") {
+		t.Errorf("frame not trimmed: %q", resp.Stack[0])
+	}
+}
+
+func TestRecoverPanics_AfterResponseStarted(t *testing.T) {
+	h := newTestServer(t).recoverPanics("/boom", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		panic("too late")
+	})
+
+	defer func() {
+		if v := recover(); v != http.ErrAbortHandler {
+			t.Errorf("recovered %v, want http.ErrAbortHandler to cut the connection", v)
+		}
+	}()
+	h(httptest.NewRecorder(), httptest.NewRequest("GET", "/boom", nil))
+}
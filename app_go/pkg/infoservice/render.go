@@ -0,0 +1,408 @@
+package infoservice
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// ==================== CONTENT NEGOTIATION ====================
+
+// responseFormat is one representation the info endpoint can produce.
+type responseFormat struct {
+	name        string
+	contentType string
+	render      func(w io.Writer, v interface{}) error
+}
+
+// Order matters: it is the server preference when the client accepts
+// several types with the same quality, and JSON comes first so that clients
+// sending "*/*" (curl, Go, Python requests) keep getting JSON.
+var responseFormats = []responseFormat{
+	{"json", "application/json", renderJSON},
+	{"compact", "application/json", renderCompactJSON},
+	{"yaml", "application/yaml", renderYAML},
+	{"text", "text/plain; charset=utf-8", renderText},
+	{"html", "text/html; charset=utf-8", renderHTML},
+}
+
+// Media types that map to a format besides its canonical content type.
+var mediaTypeAliases = map[string]string{
+	"application/json":   "json",
+	"application/yaml":   "yaml",
+	"application/x-yaml": "yaml",
+	"text/yaml":          "yaml",
+	"text/x-yaml":        "yaml",
+	"text/plain":         "text",
+	"text/html":          "html",
+}
+
+func formatByName(name string) (responseFormat, bool) {
+	for _, f := range responseFormats {
+		if f.name == name {
+			return f, true
+		}
+	}
+	return responseFormat{}, false
+}
+
+// negotiateFormat picks the representation for r: the ?format= query
+// parameter wins, then the Accept header, then JSON.
+func negotiateFormat(r *http.Request) (responseFormat, bool) {
+	if name := r.URL.Query().Get("format"); name != "" {
+		return formatByName(strings.ToLower(name))
+	}
+
+	accept := r.Header.Get("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return responseFormats[0], true
+	}
+
+	best, bestQ := "", 0.0
+	for _, item := range parseAccept(accept) {
+		if item.q <= bestQ {
+			continue
+		}
+		if name := matchMediaRange(item.mediaRange); name != "" {
+			best, bestQ = name, item.q
+		}
+	}
+	if best == "" {
+		return responseFormat{}, false
+	}
+	return formatByName(best)
+}
+
+type acceptItem struct {
+	mediaRange string
+	q          float64
+}
+
+// parseAccept splits an Accept header into media ranges with their quality,
+// preserving header order for ranges of equal quality.
+func parseAccept(header string) []acceptItem {
+	var items []acceptItem
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if raw, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(raw, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > 0 {
+			items = append(items, acceptItem{mediaRange: mediaType, q: q})
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].q > items[j].q })
+	return items
+}
+
+// matchMediaRange returns the first format (in server preference order)
+// covered by mediaRange, or "" when none is.
+func matchMediaRange(mediaRange string) string {
+	if name, ok := mediaTypeAliases[mediaRange]; ok {
+		return name
+	}
+	if mediaRange == "*/*" {
+		return responseFormats[0].name
+	}
+	if strings.HasSuffix(mediaRange, "/*") {
+		prefix := strings.TrimSuffix(mediaRange, "*")
+		for _, f := range responseFormats {
+			if strings.HasPrefix(f.contentType, prefix) {
+				return f.name
+			}
+		}
+	}
+	return ""
+}
+
+func writeNotAcceptable(w http.ResponseWriter, r *http.Request) {
+	var supported []string
+	for _, f := range responseFormats {
+		supported = append(supported, f.name)
+	}
+	writeJSONError(w, r, http.StatusNotAcceptable, negotiateLocale(r).messagef(
+		"Supported formats: %s (use the Accept header or the ?format= query parameter)", strings.Join(supported, ", ")))
+}
+
+// writeFormatted renders v with f into a buffer first, so that a rendering
+// error can still become a clean 500 instead of a truncated body.
+func (s *Server) writeFormatted(w http.ResponseWriter, r *http.Request, f responseFormat, status int, v interface{}) {
+	body, err := renderBody(f, v)
+	if err != nil {
+		s.log.errorf("http", "Error rendering %s: %v", f.name, err)
+		writeJSONError(w, r, http.StatusInternalServerError, "Failed to render response")
+		return
+	}
+
+	w.Header().Set("Content-Type", f.contentType)
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+func renderBody(f responseFormat, v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := f.render(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ==================== RENDERERS ====================
+
+func renderJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func renderCompactJSON(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+// renderYAML writes v as block-style YAML. Struct fields use their json tag
+// names and keep declaration order; strings are quoted when a YAML parser
+// would otherwise read them as another type.
+func renderYAML(w io.Writer, v interface{}) error {
+	var buf bytes.Buffer
+	writeYAML(&buf, toTree(v, treeOptions{}), 0)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func writeYAML(buf *bytes.Buffer, node interface{}, indent int) {
+	pad := strings.Repeat("  ", indent)
+
+	switch n := node.(type) {
+	case *orderedMap:
+		for _, k := range n.Keys() {
+			child, _ := n.Get(k)
+			if isComposite(child) {
+				fmt.Fprintf(buf, "%s%s:\n", pad, k)
+				writeYAML(buf, child, indent+1)
+			} else {
+				fmt.Fprintf(buf, "%s%s: %s\n", pad, k, yamlScalar(child))
+			}
+		}
+	case []interface{}:
+		for _, item := range n {
+			if !isComposite(item) {
+				fmt.Fprintf(buf, "%s- %s\n", pad, yamlScalar(item))
+				continue
+			}
+			// Render the item one level deeper, then turn the first line's
+			// indentation into the "- " marker.
+			var child bytes.Buffer
+			writeYAML(&child, item, indent+1)
+			lines := strings.SplitAfter(child.String(), "\n")
+			lines[0] = pad + "- " + strings.TrimPrefix(lines[0], pad+"  ")
+			buf.WriteString(strings.Join(lines, ""))
+		}
+	default:
+		fmt.Fprintf(buf, "%s%s\n", pad, yamlScalar(n))
+	}
+}
+
+// isComposite reports whether node needs a nested block. Empty collections
+// are written inline as {} or [].
+func isComposite(node interface{}) bool {
+	switch n := node.(type) {
+	case *orderedMap:
+		return n.Len() > 0
+	case []interface{}:
+		return len(n) > 0
+	}
+	return false
+}
+
+func yamlScalar(node interface{}) string {
+	switch n := node.(type) {
+	case nil:
+		return "null"
+	case *orderedMap:
+		return "{}"
+	case []interface{}:
+		return "[]"
+	case string:
+		if needsYAMLQuotes(n) {
+			return strconv.Quote(n)
+		}
+		return n
+	}
+	return fmt.Sprint(node)
+}
+
+// yamlTimestamp is the YAML 1.1 timestamp type; PyYAML and go-yaml v2
+// would read such a plain scalar as a time, not a string.
+var yamlTimestamp = regexp.MustCompile(`^[0-9]{4}-[0-9]{1,2}-[0-9]{1,2}` +
+	`([Tt]|[ \t]+)[0-9]{1,2}:[0-9]{2}:[0-9]{2}(\.[0-9]*)?([ \t]*Z|[-+][0-9]{1,2}(:[0-9]{2})?)?$|^[0-9]{4}-[0-9]{2}-[0-9]{2}$`)
+
+// yamlNumber is the YAML 1.1 int and float types beyond what ParseFloat
+// accepts: sexagesimal (+11:00 is 660), hex, octal, binary, underscores,
+// .inf and .nan.
+var yamlNumber = regexp.MustCompile(`^[-+]?(0b[01_]+|0x[0-9a-fA-F_]+|0o?[0-7_]+|` +
+	`[0-9][0-9_]*(:[0-5]?[0-9])*(\.[0-9_]*)?([eE][-+]?[0-9]+)?|\.[0-9_]+([eE][-+]?[0-9]+)?|\.(inf|Inf|INF))$|^\.(nan|NaN|NAN)$`)
+
+func needsYAMLQuotes(s string) bool {
+	if s == "" || strings.TrimSpace(s) != s {
+		return true
+	}
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "null", "~":
+		return true
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil || yamlNumber.MatchString(s) || yamlTimestamp.MatchString(s) {
+		return true
+	}
+	if strings.ContainsAny(s[:1], "!&*-?{}[],#|>@`\"'%") {
+		return true
+	}
+	return strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.ContainsAny(s, "\n\t")
+}
+
+// renderText writes each top-level section as an aligned key/value block;
+// lists of objects become a table with one row per item.
+func renderText(w io.Writer, v interface{}) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	root, ok := toTree(v, treeOptions{}).(*orderedMap)
+	if !ok {
+		fmt.Fprintln(tw, textValue(toTree(v, treeOptions{})))
+		return tw.Flush()
+	}
+
+	for i, name := range root.Keys() {
+		if i > 0 {
+			fmt.Fprintln(tw)
+		}
+		fmt.Fprintln(tw, strings.ToUpper(name))
+
+		section, _ := root.Get(name)
+		switch s := section.(type) {
+		case *orderedMap:
+			for _, k := range s.Keys() {
+				value, _ := s.Get(k)
+				fmt.Fprintf(tw, "  %s\t%s\n", k, textValue(value))
+			}
+		case []interface{}:
+			for _, item := range s {
+				row, ok := item.(*orderedMap)
+				if !ok {
+					fmt.Fprintf(tw, "  %s\n", textValue(item))
+					continue
+				}
+				var cells []string
+				for _, k := range row.Keys() {
+					value, _ := row.Get(k)
+					cells = append(cells, textValue(value))
+				}
+				fmt.Fprintf(tw, "  %s\n", strings.Join(cells, "\t"))
+			}
+		default:
+			fmt.Fprintf(tw, "  %s\n", textValue(s))
+		}
+	}
+	return tw.Flush()
+}
+
+func textValue(node interface{}) string {
+	switch n := node.(type) {
+	case string:
+		if n == "" {
+			return "-"
+		}
+		return n
+	case *orderedMap, []interface{}:
+		b, _ := json.Marshal(n)
+		return string(b)
+	case nil:
+		return "-"
+	}
+	return fmt.Sprint(node)
+}
+
+var htmlTemplate = template.Must(template.New("info").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Service.Name}} on {{.System.Hostname}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem; color: #222; background: #f7f7f9; }
+h1 { margin-bottom: 0; }
+.subtitle { color: #666; margin-top: .25rem; }
+.grid { display: grid; grid-template-columns: repeat(auto-fit, minmax(320px, 1fr)); gap: 1rem; }
+section { background: #fff; border-radius: 8px; padding: 1rem 1.25rem; box-shadow: 0 1px 3px rgba(0,0,0,.1); }
+h2 { font-size: 1rem; text-transform: uppercase; color: #555; }
+table { border-collapse: collapse; width: 100%; }
+td, th { text-align: left; padding: .25rem .5rem .25rem 0; vertical-align: top; }
+td:first-child { color: #666; white-space: nowrap; }
+code { font-size: .9rem; }
+</style>
+</head>
+<body>
+<h1>{{.Service.Name}} <small>{{.Service.Version}}</small></h1>
+<p class="subtitle">{{.Service.Description}} &middot; {{.Service.Framework}}</p>
+<div class="grid">
+<section>
+<h2>System</h2>
+<table>
+<tr><td>Hostname</td><td>{{.System.Hostname}}</td></tr>
+<tr><td>Platform</td><td>{{.System.Platform}} / {{.System.Architecture}}</td></tr>
+<tr><td>CPUs</td><td>{{.System.CPUCount}}</td></tr>
+<tr><td>Go</td><td>{{.System.GoVersion}}</td></tr>
+</table>
+</section>
+<section>
+<h2>Runtime</h2>
+<table>
+<tr><td>Uptime</td><td>{{.Runtime.UptimeHuman}} ({{.Runtime.UptimeSeconds}}s)</td></tr>
+<tr><td>Current time</td><td>{{.Runtime.CurrentTime}}</td></tr>
+<tr><td>Timezone</td><td>{{.Runtime.Timezone}}</td></tr>
+</table>
+</section>
+<section>
+<h2>Request</h2>
+<table>
+<tr><td>Client IP</td><td>{{.Request.ClientIP}}</td></tr>
+<tr><td>User agent</td><td>{{.Request.UserAgent}}</td></tr>
+<tr><td>Method</td><td>{{.Request.Method}} {{.Request.Path}}</td></tr>
+</table>
+</section>
+<section>
+<h2>Endpoints</h2>
+<table>
+{{range .Endpoints}}<tr><td><code>{{.Method}} {{.Path}}</code></td><td>{{.Description}}</td></tr>
+{{end}}</table>
+</section>
+</div>
+</body>
+</html>
+`))
+
+// renderHTML renders the dashboard template. Only ServiceInfo has a page;
+// other values fall back to a preformatted JSON document.
+func renderHTML(w io.Writer, v interface{}) error {
+	if info, ok := v.(ServiceInfo); ok {
+		return htmlTemplate.Execute(w, info)
+	}
+
+	body, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "<!DOCTYPE html>\n<pre>%s</pre>\n", template.HTMLEscapeString(string(body)))
+	return err
+}
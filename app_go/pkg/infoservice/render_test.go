@@ -0,0 +1,214 @@
+package infoservice
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	testCases := []struct {
+		name   string
+		query  string
+		accept string
+		want   string
+		ok     bool
+	}{
+		{"no accept", "", "", "json", true},
+		{"wildcard", "", "*/*", "json", true},
+		{"json", "", "application/json", "json", true},
+		{"yaml", "", "application/yaml", "yaml", true},
+		{"x-yaml", "", "application/x-yaml", "yaml", true},
+		{"plain text", "", "text/plain", "text", true},
+		{"browser", "", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "html", true},
+		{"quality order", "", "text/html;q=0.5, text/plain;q=0.9", "text", true},
+		{"text wildcard", "", "text/*", "text", true},
+		{"q zero excluded", "", "text/html;q=0, application/json", "json", true},
+		{"unsupported", "", "application/xml", "", false},
+		{"unsupported only", "", "image/png, application/pdf", "", false},
+		{"query wins", "format=yaml", "text/html", "yaml", true},
+		{"query compact", "format=compact", "", "compact", true},
+		{"query case-insensitive", "format=HTML", "", "html", true},
+		{"query unknown", "format=xml", "", "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/?"+tc.query, nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			f, ok := negotiateFormat(req)
+			if ok != tc.ok || f.name != tc.want {
+				t.Errorf("negotiateFormat = (%q, %v), want (%q, %v)", f.name, ok, tc.want, tc.ok)
+			}
+		})
+	}
+}
+
+func getInfo(t *testing.T, target, accept string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest("GET", target, nil)
+	req.Header.Set("User-Agent", "<script>alert(1)</script>")
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	newTestServer(t).mainHandler(w, req)
+	return w
+}
+
+func TestMainHandler_Formats(t *testing.T) {
+	testCases := []struct {
+		target      string
+		accept      string
+		contentType string
+		contains    []string
+	}{
+		{"/", "", "application/json", []string{"{\n  \"service\": {"}},
+		{"/?format=compact", "", "application/json", []string{`{"service":{"name":"devops-info-service"`}},
+		{"/", "application/yaml", "application/yaml", []string{"service:\n  name: devops-info-service\n", "endpoints:\n  - path: /\n    method: GET\n"}},
+		{"/?format=text", "", "text/plain; charset=utf-8", []string{"SERVICE\n", "ENDPOINTS\n", "/health"}},
+		{"/", "text/html", "text/html; charset=utf-8", []string{"<!DOCTYPE html>", "devops-info-service", "&lt;script&gt;"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.target+" "+tc.accept, func(t *testing.T) {
+			w := getInfo(t, tc.target, tc.accept)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", w.Code)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != tc.contentType {
+				t.Errorf("Content-Type = %s, want %s", ct, tc.contentType)
+			}
+			if vary := w.Header().Get("Vary"); !strings.Contains(vary, "Accept") {
+				t.Errorf("Vary = %q, want it to include Accept", vary)
+			}
+			body := w.Body.String()
+			for _, s := range tc.contains {
+				if !strings.Contains(body, s) {
+					t.Errorf("body missing %q:\n%s", s, body)
+				}
+			}
+			if strings.HasPrefix(tc.contentType, "text/html") && strings.Contains(body, "<script>") {
+				t.Error("user agent must be escaped in the HTML page")
+			}
+		})
+	}
+}
+
+func TestMainHandler_CompactJSONIsSingleLine(t *testing.T) {
+	w := getInfo(t, "/?format=compact", "")
+	body := strings.TrimSuffix(w.Body.String(), "\n")
+	if strings.Contains(body, "\n") {
+		t.Error("compact JSON should be a single line")
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &data); err != nil {
+		t.Errorf("invalid JSON: %v", err)
+	}
+}
+
+func TestMainHandler_NotAcceptable(t *testing.T) {
+	for _, target := range []string{"/", "/?format=xml"} {
+		w := getInfo(t, target, "application/xml")
+		if w.Code != http.StatusNotAcceptable {
+			t.Errorf("%s: status = %d, want 406", target, w.Code)
+		}
+		var data map[string]string
+		if err := json.NewDecoder(w.Body).Decode(&data); err != nil {
+			t.Fatalf("invalid JSON error body: %v", err)
+		}
+		if !strings.Contains(data["message"], "yaml") {
+			t.Errorf("406 body should list supported formats, got %q", data["message"])
+		}
+	}
+}
+
+func TestRenderYAML(t *testing.T) {
+	v := struct {
+		Name    string            `json:"name"`
+		Port    string            `json:"port"`
+		Empty   string            `json:"empty"`
+		Enabled bool              `json:"enabled"`
+		Tags    []string          `json:"tags"`
+		None    []string          `json:"none"`
+		Labels  map[string]string `json:"labels"`
+		Time    string            `json:"current_time"`
+		Dates   []string          `json:"dates"`
+		Numbers []string          `json:"numbers"`
+		skipped string
+	}{
+		Name:    "svc: main",
+		Port:    "8000",
+		Enabled: true,
+		Tags:    []string{"a", "yes"},
+		Labels:  map[string]string{"z": "1.0", "a": "x"},
+		Time:    "2026-03-01T15:00:00.123+03:00",
+		Dates:   []string{"2026-03-01", "2026-03-01 15:00:00Z", "2026-03-01T15:00:00Z", "2026-03-01Tnoon"},
+		Numbers: []string{"+11:00", "1:30", "0x1F", "0o17", "017", "1_000", ".inf", "-.Inf", ".NaN", "12:75", "1.2.3"},
+		skipped: "hidden",
+	}
+
+	var buf bytes.Buffer
+	if err := renderYAML(&buf, v); err != nil {
+		t.Fatalf("renderYAML: %v", err)
+	}
+
+	want := `name: "svc: main"
+port: "8000"
+empty: ""
+enabled: true
+tags:
+  - a
+  - "yes"
+none: []
+labels:
+  a: x
+  z: "1.0"
+current_time: "2026-03-01T15:00:00.123+03:00"
+dates:
+  - "2026-03-01"
+  - "2026-03-01 15:00:00Z"
+  - "2026-03-01T15:00:00Z"
+  - 2026-03-01Tnoon
+numbers:
+  - "+11:00"
+  - "1:30"
+  - "0x1F"
+  - "0o17"
+  - "017"
+  - "1_000"
+  - ".inf"
+  - "-.Inf"
+  - ".NaN"
+  - 12:75
+  - 1.2.3
+`
+	if buf.String() != want {
+		t.Errorf("renderYAML =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestRenderText_Aligned(t *testing.T) {
+	v := struct {
+		Section struct {
+			A    string `json:"a"`
+			Long string `json:"long_name"`
+		} `json:"section"`
+	}{}
+	v.Section.A, v.Section.Long = "1", ""
+
+	var buf bytes.Buffer
+	if err := renderText(&buf, v); err != nil {
+		t.Fatalf("renderText: %v", err)
+	}
+
+	want := "SECTION\n  a          1\n  long_name  -\n"
+	if buf.String() != want {
+		t.Errorf("renderText =\n%q\nwant\n%q", buf.String(), want)
+	}
+}
@@ -0,0 +1,46 @@
+package infoservice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithRequestID(t *testing.T) {
+	var seen string
+	h := withRequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = requestIDFrom(r.Context())
+	}))
+
+	testCases := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{"generated", "", false},
+		{"from proxy", "3f2c9a1e-7b4d-4c1a-9e8f-0a1b2c3d4e5f", true},
+		{"unsafe characters", "abc\ndef", false},
+		{"too long", string(make([]byte, 129)), false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tc.incoming != "" {
+				req.Header.Set(requestIDHeader, tc.incoming)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			got := w.Header().Get(requestIDHeader)
+			if got != seen {
+				t.Errorf("header %q differs from the context %q", got, seen)
+			}
+			if tc.keep && got != tc.incoming {
+				t.Errorf("ID = %q, want the incoming %q", got, tc.incoming)
+			}
+			if !tc.keep && (len(got) != 16 || got == tc.incoming) {
+				t.Errorf("ID = %q, want a fresh 16-character ID", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package infoservice
+
+import (
+	"net/http"
+)
+
+// ==================== ROUTE REGISTRY ====================
+
+// apiRoute describes one endpoint. The registry is the single source for
+// the mux, the endpoints list in ServiceInfo and the OpenAPI document.
+type apiRoute struct {
+	Path    string
+	Method  string
+	Summary string
+	Handler http.HandlerFunc
+	Params  []apiParam
+	// Request is an example JSON request body; its type gives the schema.
+	Request interface{}
+	// Response is a value of the 200 JSON body type, used for its schema;
+	// nil means a free-form JSON object.
+	Response interface{}
+	// ContentType replaces JSON as the 200 body of routes that answer
+	// plain text.
+	ContentType string
+	// AltContentTypes lists non-JSON representations of the 200 response.
+	AltContentTypes []string
+	// Errors lists the statuses that return an ErrorResp body.
+	Errors []int
+	// Streaming routes answer 200 with text/event-stream instead of JSON.
+	Streaming bool
+	// WebSocket routes answer 101 Switching Protocols instead of 200.
+	WebSocket bool
+	// Admin routes need the ADMIN_TOKEN bearer token and are left out of
+	// the public endpoints list.
+	Admin bool
+	// Priority routes (probes, metrics) are never shed, so an overloaded
+	// pod is neither restarted nor left unobserved.
+	Priority bool
+}
+
+// apiParam is a query parameter.
+type apiParam struct {
+	Name        string
+	Description string
+	Enum        []string
+}
+
+var volatileParam = apiParam{
+	Name:        "volatile",
+	Description: "set to false to leave out timestamps and uptime, which makes the ETag stable",
+	Enum:        []string{"true", "false"},
+}
+
+// routes returns the registry, with the handlers bound to s.
+func (s *Server) routes() []apiRoute {
+	var formats []string
+	var altTypes []string
+	for _, f := range responseFormats {
+		formats = append(formats, f.name)
+		if f.contentType != "application/json" {
+			altTypes = append(altTypes, f.contentType)
+		}
+	}
+
+	return []apiRoute{
+		{
+			Path:    "/",
+			Method:  http.MethodGet,
+			Summary: "Service information",
+			Handler: s.mainHandler,
+			Params: []apiParam{
+				{Name: "format", Description: "response representation, overrides the Accept header", Enum: formats},
+				volatileParam,
+				{Name: "uptime_format", Description: "how runtime.uptime_human is written: human (26 hours, 3 minutes), full (1 day, 2 hours, 3 minutes, 4 seconds), iso8601 (P1DT2H3M4S) or compact (1d2h3m4s)", Enum: uptimeFormats},
+				{Name: "tz", Description: "IANA timezone of runtime.current_time, e.g. Europe/Moscow, overrides TIMEZONE"},
+				{Name: "lang", Description: "language of uptime_human and error messages, overrides the Accept-Language header; others fall back to English", Enum: localeTags()},
+				{Name: "fields", Description: "comma-separated fields to return, e.g. system.hostname,runtime.uptime_seconds; the response then holds only those fields"},
+				{Name: "sections", Description: "comma-separated top-level sections to return, e.g. system,runtime; the response then holds only those sections"},
+			},
+			Response:        ServiceInfo{},
+			AltContentTypes: altTypes,
+			Errors:          []int{http.StatusBadRequest, http.StatusMethodNotAllowed, http.StatusNotAcceptable, http.StatusServiceUnavailable},
+		},
+		{
+			Path:     "/health",
+			Method:   http.MethodGet,
+			Summary:  "Health check",
+			Handler:  s.healthHandler,
+			Params:   []apiParam{volatileParam},
+			Response: HealthResp{},
+			Errors:   []int{http.StatusBadRequest, http.StatusMethodNotAllowed},
+			Priority: true,
+		},
+		{
+			Path:     "/ready",
+			Method:   http.MethodGet,
+			Summary:  "Readiness check",
+			Handler:  s.readyHandler,
+			Response: ReadyResp{},
+			Errors:   []int{http.StatusMethodNotAllowed, http.StatusServiceUnavailable},
+			Priority: true,
+		},
+		{
+			Path:     "/visits",
+			Method:   http.MethodGet,
+			Summary:  "Visit counter",
+			Handler:  s.visitsHandler,
+			Response: VisitsResp{},
+			Errors:   []int{http.StatusMethodNotAllowed, http.StatusInternalServerError, http.StatusServiceUnavailable},
+		},
+		{
+			Path:    "/events",
+			Method:  http.MethodGet,
+			Summary: "Live runtime stats (Server-Sent Events)",
+			Handler: s.eventsHandler,
+			Params: []apiParam{
+				{Name: "lastEventId", Description: "resume after this event ID, for clients that cannot send the Last-Event-ID header"},
+			},
+			Errors:    []int{http.StatusBadRequest, http.StatusMethodNotAllowed, http.StatusServiceUnavailable},
+			Streaming: true,
+		},
+		{
+			Path:      "/ws",
+			Method:    http.MethodGet,
+			Summary:   "Interactive diagnostics (WebSocket)",
+			Handler:   s.wsHandler,
+			Errors:    []int{http.StatusBadRequest, http.StatusForbidden, http.StatusMethodNotAllowed, http.StatusUpgradeRequired, http.StatusServiceUnavailable},
+			WebSocket: true,
+		},
+		{
+			Path:    "/admin/logs",
+			Method:  http.MethodGet,
+			Summary: "Recent log records",
+			Handler: s.logsHandler,
+			Params: []apiParam{
+				{Name: "level", Description: "minimum level", Enum: logLevelNames},
+				{Name: "component", Description: "only records from this component, e.g. http, health, storage"},
+				{Name: "q", Description: "case-insensitive substring of the message"},
+				{Name: "since", Description: "RFC 3339 time, or a duration such as 15m meaning that long ago"},
+				{Name: "until", Description: "RFC 3339 time, or a duration meaning that long ago"},
+				{Name: "limit", Description: "return only the newest N matching records"},
+				{Name: "follow", Description: "keep the connection open and stream new records as NDJSON", Enum: []string{"true", "false"}},
+			},
+			Response:        LogsResp{},
+			AltContentTypes: []string{"application/x-ndjson"},
+			Errors:          []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusMethodNotAllowed, http.StatusServiceUnavailable},
+			Admin:           true,
+		},
+		{
+			Path:     "/admin/log-level",
+			Method:   http.MethodGet,
+			Summary:  "Current log levels",
+			Handler:  s.logLevelHandler,
+			Response: LogLevelResp{},
+			Errors:   []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusMethodNotAllowed},
+			Admin:    true,
+		},
+		{
+			Path:     "/admin/log-level",
+			Method:   http.MethodPut,
+			Summary:  "Change the global or a component's log level",
+			Handler:  s.logLevelHandler,
+			Request:  LogLevelUpdate{Level: "info"},
+			Response: LogLevelResp{},
+			Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusMethodNotAllowed},
+			Admin:    true,
+		},
+		{
+			Path:     "/admin/chaos",
+			Method:   http.MethodGet,
+			Summary:  "Current chaos rules",
+			Handler:  s.chaosHandler,
+			Response: ChaosResp{},
+			Errors:   []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusMethodNotAllowed},
+			Admin:    true,
+		},
+		{
+			Path:     "/admin/chaos",
+			Method:   http.MethodPut,
+			Summary:  "Replace the chaos rules",
+			Handler:  s.chaosHandler,
+			Request:  ChaosUpdate{Rules: []ChaosRule{{Route: "/", ErrorRate: 0.5, Status: http.StatusServiceUnavailable}}},
+			Response: ChaosResp{},
+			Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusMethodNotAllowed, http.StatusConflict},
+			Admin:    true,
+		},
+		{
+			Path:     "/admin/maintenance",
+			Method:   http.MethodGet,
+			Summary:  "Maintenance mode state",
+			Handler:  s.maintenanceHandler,
+			Response: MaintenanceResp{},
+			Errors:   []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusMethodNotAllowed},
+			Admin:    true,
+		},
+		{
+			Path:     "/admin/maintenance",
+			Method:   http.MethodPut,
+			Summary:  "Turn maintenance mode on or off",
+			Handler:  s.maintenanceHandler,
+			Request:  MaintenanceUpdate{Enabled: false},
+			Response: MaintenanceResp{},
+			Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusMethodNotAllowed},
+			Admin:    true,
+		},
+		{
+			Path:        "/metrics",
+			Method:      http.MethodGet,
+			Summary:     "Prometheus metrics",
+			Handler:     s.metricsHandler,
+			ContentType: "text/plain",
+			Errors:      []int{http.StatusMethodNotAllowed},
+			Priority:    true,
+		},
+		{
+			Path:    "/openapi.json",
+			Method:  http.MethodGet,
+			Summary: "OpenAPI specification",
+			Handler: s.openAPIHandler,
+			Errors:  []int{http.StatusMethodNotAllowed},
+		},
+	}
+}
+
+// endpointList is the endpoints section of ServiceInfo.
+func (s *Server) endpointList() []Endpoint {
+	var endpoints []Endpoint
+	for _, rt := range s.routes() {
+		if rt.Admin {
+			continue
+		}
+		endpoints = append(endpoints, Endpoint{Path: rt.Path, Method: rt.Method, Description: rt.Summary})
+	}
+	return endpoints
+}
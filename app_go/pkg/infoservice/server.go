@@ -0,0 +1,312 @@
+package infoservice
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ==================== SERVER ====================
+
+// Server is one instance of the service: its configuration, what its
+// handlers depend on (clock, hostname, environment, logger, listener) and
+// the state they keep, so tests can run isolated instances side by side.
+// Only the Prometheus counters on /metrics are shared by the process.
+type Server struct {
+	cfg            Config
+	clock          Clock
+	lookupHostname func() (string, error)
+	lookupEnv      func(string) (string, bool)
+	printf         func(format string, v ...interface{})
+	chaosRand      func() float64
+	log            *logger
+	listener       net.Listener
+	timezone       *time.Location
+	readiness      *readinessState
+	maintenance    *maintenanceState
+	// logTail is nil when the configuration has no LOG_BUFFER_SIZE.
+	logTail *logRing
+	// Set by Serve as the configuration asks; nil means off.
+	chaos       *chaosState
+	shed        *loadShedder
+	timeSync    *timeSyncer
+	events      *eventBroker
+	diagnostics *wsHub
+	// Set by run; nil means no visit counter, and access lines go to the
+	// logger.
+	visits        *visitStore
+	accessLogFile *rotatingFile
+	// requests counts what the handler chain served, for the request rate
+	// of /events and /ws.
+	requests atomic.Uint64
+	// deployment comes from DEPLOY_COLOR, DEPLOY_TRACK and
+	// DEPLOY_REVISION, so a response tells which stack of a blue/green or
+	// canary rollout served it.
+	deployment Deployment
+	// hostName is looked up once, by host.
+	hostOnce sync.Once
+	hostName string
+	// started is set once, by markStarted.
+	startOnce sync.Once
+	started   time.Time
+	// hasConfig is set by WithConfig; otherwise NewServer reads the
+	// configuration through lookupEnv.
+	hasConfig bool
+}
+
+// Option configures a Server built by NewServer.
+type Option func(*Server)
+
+// WithConfig uses cfg as is instead of reading the environment.
+func WithConfig(cfg Config) Option {
+	return func(s *Server) { s.cfg, s.hasConfig = cfg, true }
+}
+
+// WithClock replaces the system clock.
+func WithClock(c Clock) Option {
+	return func(s *Server) { s.clock = c }
+}
+
+// WithHostname replaces os.Hostname.
+func WithHostname(lookup func() (string, error)) Option {
+	return func(s *Server) { s.lookupHostname = lookup }
+}
+
+// WithLookupEnv replaces os.LookupEnv for reading the configuration.
+func WithLookupEnv(lookupEnv func(string) (string, bool)) Option {
+	return func(s *Server) { s.lookupEnv = lookupEnv }
+}
+
+// WithLogger sends the Server's log lines, access log included, to
+// printf, for example the Printf method of a *log.Logger, instead of
+// log.Printf.
+func WithLogger(printf func(format string, v ...interface{})) Option {
+	return func(s *Server) { s.printf = printf }
+}
+
+// withChaosRand replaces rand.Float64 for rolling against chaos error rates.
+func withChaosRand(rand func() float64) Option {
+	return func(s *Server) { s.chaosRand = rand }
+}
+
+// WithListener serves on ln instead of listening on HOST:PORT.
+func WithListener(ln net.Listener) Option {
+	return func(s *Server) { s.listener = ln }
+}
+
+// NewServer builds a Server. Without WithConfig the configuration comes
+// from the environment and is validated.
+func NewServer(opts ...Option) (*Server, error) {
+	s := &Server{
+		clock:          ClockFunc(time.Now),
+		lookupHostname: os.Hostname,
+		lookupEnv:      os.LookupEnv,
+		printf:         log.Printf,
+		chaosRand:      rand.Float64,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if !s.hasConfig {
+		cfg, err := LoadConfig(s.lookupEnv)
+		if err != nil {
+			return nil, err
+		}
+		s.cfg = cfg
+	}
+	tz, err := loadTimezone(s.cfg.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TIMEZONE: %w", err)
+	}
+	s.timezone = tz
+	s.deployment = s.cfg.deployment()
+
+	global, components, err := s.cfg.logLevels()
+	if err != nil {
+		return nil, err
+	}
+	s.log = newLogger(s.printf, s.now)
+	s.log.levels.configure(global, components)
+	if s.cfg.LogBufferSize > 0 {
+		s.logTail = newLogRing(s.cfg.LogBufferSize)
+		s.log.addSink(s.logTail.add)
+	}
+	s.readiness = newReadiness()
+	s.maintenance = newMaintenanceState(s.log, s.now, s.readiness)
+	return s, nil
+}
+
+// host is the hostname, looked up the first time it is needed and reused
+// after that: X-Served-By needs it on every request.
+func (s *Server) host() string {
+	s.hostOnce.Do(func() { s.hostName = s.hostname() })
+	return s.hostName
+}
+
+// markStarted records the start time the first time it is called: when
+// Serve begins serving, or, for handlers mounted elsewhere, at the first
+// request.
+func (s *Server) markStarted() {
+	s.startOnce.Do(func() { s.started = s.now() })
+}
+
+// ==================== HTTP SERVER ====================
+
+// newHTTPServer builds an http.Server with the timeouts and header limit from
+// cfg, so slow or stuck clients cannot hold connections open forever.
+func newHTTPServer(cfg Config, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              cfg.addr(),
+		Handler:           handler,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}
+}
+
+// Handler registers the routes, each behind panic recovery and, unless
+// it is an admin route, chaos mode and load shedding, and wraps
+// them in Middleware.
+// Routes sharing a path share a handler that dispatches on the method, so
+// each path is registered once.
+func (s *Server) Handler() http.Handler {
+	cfg := s.cfg
+	mux := http.NewServeMux()
+	registered := map[string]bool{}
+	for _, rt := range s.routes() {
+		if registered[rt.Path] {
+			continue
+		}
+		registered[rt.Path] = true
+		h := rt.Handler
+		if rt.Admin {
+			h = requireAdmin(cfg.AdminToken, h)
+		} else {
+			h = s.chaosFaults(rt.Path, h)
+		}
+		// Streams and WebSockets would hold a slot for as long as they are
+		// open; they have limits of their own.
+		if !rt.Admin && !rt.Priority && !rt.Streaming && !rt.WebSocket {
+			h = s.shedLoad(h)
+		}
+		h = s.recoverPanics(rt.Path, h)
+		mux.HandleFunc(rt.Path, h)
+	}
+	return s.Middleware(mux)
+}
+
+// Middleware wraps next with what every response of the service gets:
+// compression, the request body limit, the access log, X-Served-By and
+// request IDs.
+func (s *Server) Middleware(next http.Handler) http.Handler {
+	cfg := s.cfg
+	handler := next
+	if cfg.Compression {
+		handler = compress(cfg.CompressMinBytes, cfg.CompressLevel, handler)
+	}
+	handler = limitRequestBody(cfg.MaxBodyBytes, handler)
+	handler = newAccessLogger(cfg, s.log, s.accessLogFile).middleware(handler)
+	return s.countRequests(withRequestID(s.withServedBy(handler)))
+}
+
+// listen opens the TCP listener for cfg and caps the number of
+// simultaneously open connections when MaxConns is set.
+func listen(cfg Config) (net.Listener, error) {
+	ln, err := net.Listen("tcp", cfg.addr())
+	if err != nil {
+		return nil, err
+	}
+	if cfg.MaxConns > 0 {
+		ln = newLimitListener(ln, cfg.MaxConns)
+	}
+	return ln, nil
+}
+
+// ==================== REQUEST LIMITS ====================
+
+// limitRequestBody rejects requests whose declared body is larger than max
+// and caps the bytes a handler can read from bodies of unknown length.
+func limitRequestBody(max int64, next http.Handler) http.Handler {
+	if max <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > max {
+			writeJSONError(w, r, http.StatusRequestEntityTooLarge, "Request body is too large")
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, max)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeJSONError answers with an ErrorResp whose message is in the
+// language negotiated for r.
+func writeJSONError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	loc := negotiateLocale(r)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Language", loc.tag)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResp{
+		Error:   http.StatusText(status),
+		Message: loc.message(message),
+	})
+}
+
+// limitListener blocks Accept while max connections are open.
+type limitListener struct {
+	net.Listener
+	sem       chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newLimitListener(ln net.Listener, max int) *limitListener {
+	return &limitListener{
+		Listener: ln,
+		sem:      make(chan struct{}, max),
+		done:     make(chan struct{}),
+	}
+}
+
+func (l *limitListener) Accept() (net.Conn, error) {
+	select {
+	case l.sem <- struct{}{}:
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		<-l.sem
+		return nil, err
+	}
+	return &limitConn{Conn: conn, release: func() { <-l.sem }}, nil
+}
+
+func (l *limitListener) Close() error {
+	err := l.Listener.Close()
+	l.closeOnce.Do(func() { close(l.done) })
+	return err
+}
+
+type limitConn struct {
+	net.Conn
+	releaseOnce sync.Once
+	release     func()
+}
+
+func (c *limitConn) Close() error {
+	err := c.Conn.Close()
+	c.releaseOnce.Do(c.release)
+	return err
+}
@@ -0,0 +1,331 @@
+package infoservice
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestServer builds a Server from the default config that discards its
+// log lines; opts override it or replace its dependencies.
+func newTestServer(tb testing.TB, opts ...Option) *Server {
+	tb.Helper()
+	s, err := NewServer(append([]Option{WithConfig(DefaultConfig()), WithLogger(discardLogs)}, opts...)...)
+	if err != nil {
+		tb.Fatalf("NewServer: %v", err)
+	}
+	return s
+}
+
+// startTestServer serves the handler of a Server built from cfg on a random
+// local port through the same listener and http.Server setup that run()
+// uses.
+func startTestServer(t *testing.T, cfg Config) string {
+	t.Helper()
+	cfg.Host, cfg.Port = "127.0.0.1", "0"
+
+	ln, err := listen(cfg)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := newHTTPServer(cfg, newTestServer(t, WithConfig(cfg)).Handler())
+	go srv.Serve(ln)
+	t.Cleanup(func() { srv.Close() })
+
+	return ln.Addr().String()
+}
+
+// waitForClose reads from conn until the server closes it. It fails the test
+// if the connection is still open after limit.
+func waitForClose(t *testing.T, conn net.Conn, limit time.Duration) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(limit))
+	_, err := io.Copy(io.Discard, conn)
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("server kept the connection open for more than %s", limit)
+	}
+}
+
+func TestNewServer_AppliesConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	srv := newHTTPServer(cfg, http.NotFoundHandler())
+
+	if srv.Addr != "0.0.0.0:8000" {
+		t.Errorf("Addr = %s, want 0.0.0.0:8000", srv.Addr)
+	}
+	if srv.ReadTimeout != cfg.ReadTimeout || srv.ReadHeaderTimeout != cfg.ReadHeaderTimeout ||
+		srv.WriteTimeout != cfg.WriteTimeout || srv.IdleTimeout != cfg.IdleTimeout {
+		t.Errorf("server timeouts do not match config: %+v", srv)
+	}
+	if srv.MaxHeaderBytes != cfg.MaxHeaderBytes {
+		t.Errorf("MaxHeaderBytes = %d, want %d", srv.MaxHeaderBytes, cfg.MaxHeaderBytes)
+	}
+}
+
+func TestServer_SlowHeadersDisconnected(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ReadHeaderTimeout = 100 * time.Millisecond
+	addr := startTestServer(t, cfg)
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	// Slowloris: start a request but never finish the header block.
+	if _, err := conn.Write([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	waitForClose(t, conn, 2*time.Second)
+}
+
+func TestServer_IdleKeepAliveDisconnected(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.IdleTimeout = 100 * time.Millisecond
+	addr := startTestServer(t, cfg)
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("GET /health HTTP/1.1\r\nHost: localhost\r\n\r\n")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatalf("read response: %v", err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	waitForClose(t, conn, 2*time.Second)
+}
+
+func TestServer_HeaderTooLarge(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxHeaderBytes = 1024
+	addr := startTestServer(t, cfg)
+
+	req, _ := http.NewRequest("GET", "http://"+addr+"/health", nil)
+	req.Header.Set("X-Padding", strings.Repeat("a", 8192))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusRequestHeaderFieldsTooLarge {
+		t.Errorf("status = %d, want 431", resp.StatusCode)
+	}
+}
+
+func TestLimitRequestBody(t *testing.T) {
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	h := limitRequestBody(16, echo)
+
+	testCases := []struct {
+		name          string
+		body          string
+		unknownLength bool
+		want          int
+	}{
+		{"small body", "hello", false, http.StatusOK},
+		{"declared too large", strings.Repeat("x", 17), false, http.StatusRequestEntityTooLarge},
+		{"streamed too large", strings.Repeat("x", 64), true, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/", strings.NewReader(tc.body))
+			if tc.unknownLength {
+				req.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if w.Code != tc.want {
+				t.Errorf("status = %d, want %d", w.Code, tc.want)
+			}
+		})
+	}
+}
+
+func TestLimitListener_BlocksAtMax(t *testing.T) {
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	ln := newLimitListener(inner, 1)
+	defer ln.Close()
+
+	accepted := make(chan net.Conn, 2)
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- c
+		}
+	}()
+
+	for i := 0; i < 2; i++ {
+		c, err := net.Dial("tcp", inner.Addr().String())
+		if err != nil {
+			t.Fatalf("dial %d: %v", i, err)
+		}
+		defer c.Close()
+	}
+
+	first := <-accepted
+	select {
+	case <-accepted:
+		t.Fatal("second connection accepted while the limit was reached")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	first.Close()
+	select {
+	case c := <-accepted:
+		c.Close()
+	case <-time.After(2 * time.Second):
+		t.Fatal("second connection not accepted after the first was closed")
+	}
+}
+
+func TestLimitListener_CloseUnblocksAccept(t *testing.T) {
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	ln := newLimitListener(inner, 1)
+	ln.sem <- struct{}{} // simulate one open connection
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := ln.Accept()
+		errc <- err
+	}()
+
+	ln.Close()
+	select {
+	case err := <-errc:
+		if !errors.Is(err, net.ErrClosed) {
+			t.Errorf("Accept error = %v, want net.ErrClosed", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Accept still blocked after Close")
+	}
+}
+
+func TestServe_GracefulShutdown(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Host, cfg.Port = "127.0.0.1", "0"
+	cfg.ShutdownDrainDelay = 0
+	ln, err := listen(cfg)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	srv := newTestServer(t, WithConfig(cfg), WithListener(ln))
+	go func() { done <- srv.Serve(ctx) }()
+
+	// An open event stream must not hold up the shutdown.
+	resp, err := http.Get("http://" + ln.Addr().String() + "/events")
+	if err != nil {
+		t.Fatalf("GET /events: %v", err)
+	}
+	defer resp.Body.Close()
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("serve = %v, want nil after a clean shutdown", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("serve did not return after the context was cancelled")
+	}
+
+	if ready, _, _ := srv.readiness.Status(); ready {
+		t.Error("still ready after shutdown")
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), ": server shutting down") {
+		t.Errorf("stream = %q, want it to end with the shutdown comment", body)
+	}
+}
+
+func TestServe_DrainDelay(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Host, cfg.Port = "127.0.0.1", "0"
+	cfg.ShutdownDrainDelay = 300 * time.Millisecond
+	ln, err := listen(cfg)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	srv := newTestServer(t, WithConfig(cfg), WithListener(ln))
+	go func() { done <- srv.Serve(ctx) }()
+	get := func(path string) int {
+		resp, err := http.Get("http://" + ln.Addr().String() + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := get("/ready"); code != http.StatusOK {
+		t.Fatalf("/ready before shutdown = %d, want 200", code)
+	}
+
+	cancel()
+	start := time.Now()
+	for get("/ready") != http.StatusServiceUnavailable {
+		if time.Since(start) > cfg.ShutdownDrainDelay/2 {
+			t.Fatal("/ready did not fail while draining")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if code := get("/health"); code != http.StatusOK {
+		t.Errorf("/health while draining = %d, want 200", code)
+	}
+	select {
+	case <-done:
+		t.Fatal("serve returned before the drain delay was over")
+	default:
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("serve = %v, want nil after a clean shutdown", err)
+		}
+		if elapsed := time.Since(start); elapsed < cfg.ShutdownDrainDelay {
+			t.Errorf("shut down after %s, want at least the %s drain delay", elapsed, cfg.ShutdownDrainDelay)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("serve did not return after the drain delay")
+	}
+}
@@ -0,0 +1,476 @@
+package infoservice
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"reflect"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ==================== СТРУКТУРЫ ДАННЫХ ====================
+type Service struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Description string `json:"description"`
+	Framework   string `json:"framework"`
+}
+
+// Deployment tells apart the stacks of a blue/green or canary rollout.
+type Deployment struct {
+	Color    string `json:"color,omitempty"`
+	Track    string `json:"track"`
+	Revision string `json:"revision,omitempty"`
+}
+
+type System struct {
+	Hostname        string `json:"hostname"`
+	Platform        string `json:"platform"`
+	PlatformVersion string `json:"platform_version"`
+	Architecture    string `json:"architecture"`
+	CPUCount        int    `json:"cpu_count"`
+	GoVersion       string `json:"go_version"`
+}
+
+type HealthResp struct {
+	// Status is healthy, or degraded while Reasons name a problem that
+	// does not warrant a restart.
+	Status        string   `json:"status"`
+	Timestamp     string   `json:"timestamp" volatile:"true" format:"date-time"`
+	UptimeSeconds int      `json:"uptime_seconds" volatile:"true"`
+	Reasons       []string `json:"reasons,omitempty"`
+}
+
+type Runtime struct {
+	UptimeSeconds int        `json:"uptime_seconds" volatile:"true"`
+	UptimeHuman   string     `json:"uptime_human" volatile:"true"`
+	CurrentTime   string     `json:"current_time" volatile:"true" format:"date-time"`
+	Timezone      string     `json:"timezone"`
+	UTCOffset     string     `json:"utc_offset"`
+	ClockSync     *ClockSync `json:"clock_sync,omitempty"`
+}
+
+// ClockSync is the last SNTP measurement of the local clock.
+type ClockSync struct {
+	Server        string  `json:"server"`
+	OffsetSeconds float64 `json:"offset_seconds" volatile:"true"`
+	DelaySeconds  float64 `json:"delay_seconds" volatile:"true"`
+	LastSync      string  `json:"last_sync,omitempty" volatile:"true" format:"date-time"`
+	LastError     string  `json:"last_error,omitempty"`
+}
+
+type Request struct {
+	ClientIP  string `json:"client_ip"`
+	UserAgent string `json:"user_agent"`
+	Method    string `json:"method"`
+	Path      string `json:"path"`
+}
+
+type Endpoint struct {
+	Path        string `json:"path"`
+	Method      string `json:"method"`
+	Description string `json:"description"`
+}
+
+type ReadyResp struct {
+	Status string `json:"status"`
+}
+
+type VisitsResp struct {
+	Visits int `json:"visits"`
+}
+
+type LogsResp struct {
+	Capacity int         `json:"capacity"`
+	Records  []logRecord `json:"records"`
+}
+
+type LogLevelResp struct {
+	Level      string            `json:"level"`
+	Components map[string]string `json:"components"`
+	DebugUntil string            `json:"debug_until,omitempty" format:"date-time"`
+}
+
+// LogLevelUpdate is the body of PUT /admin/log-level. Without component it
+// sets the global level; level "inherit" removes a component's override.
+type LogLevelUpdate struct {
+	Level     string `json:"level"`
+	Component string `json:"component,omitempty"`
+}
+
+// ChaosRule injects faults into one route, or every non-admin route for
+// "*". Status is used for injected errors and defaults to 500.
+type ChaosRule struct {
+	Route     string  `json:"route"`
+	Latency   string  `json:"latency,omitempty"`
+	ErrorRate float64 `json:"error_rate,omitempty"`
+	Status    int     `json:"status,omitempty"`
+}
+
+type ChaosResp struct {
+	Enabled bool        `json:"enabled"`
+	Rules   []ChaosRule `json:"rules"`
+}
+
+// ChaosUpdate is the body of PUT /admin/chaos; it replaces every rule.
+type ChaosUpdate struct {
+	Rules []ChaosRule `json:"rules"`
+}
+
+// MaintenanceResp is the state of maintenance mode. Source is what turned
+// it on: admin, file or config.
+type MaintenanceResp struct {
+	Enabled bool   `json:"enabled"`
+	Message string `json:"message,omitempty"`
+	Until   string `json:"until,omitempty" format:"date-time"`
+	Since   string `json:"since,omitempty" format:"date-time"`
+	Source  string `json:"source,omitempty"`
+}
+
+// MaintenanceUpdate is the body of PUT /admin/maintenance. Until is the
+// expected end, RFC 3339; it is shown to clients but ends nothing.
+type MaintenanceUpdate struct {
+	Enabled bool   `json:"enabled"`
+	Message string `json:"message,omitempty"`
+	Until   string `json:"until,omitempty"`
+}
+
+type ErrorResp struct {
+	Error     string   `json:"error"`
+	Message   string   `json:"message"`
+	RequestID string   `json:"request_id,omitempty"`
+	Stack     []string `json:"stack,omitempty"`
+	Until     string   `json:"until,omitempty" format:"date-time"`
+}
+
+type ServiceInfo struct {
+	Service    Service    `json:"service"`
+	Deployment Deployment `json:"deployment"`
+	System     System     `json:"system"`
+	Runtime    Runtime    `json:"runtime"`
+	Request    Request    `json:"request"`
+	Endpoints  []Endpoint `json:"endpoints"`
+}
+
+// ==================== HELPER FUNCTIONS ====================
+func (s *Server) hostname() string {
+	hostname, err := s.lookupHostname()
+	if err != nil {
+		s.log.errorf("server", "Error getting hostname: %v", err)
+		return "unknown"
+	}
+	return hostname
+}
+
+func getClientIP(r *http.Request) string {
+	forwarded := r.Header.Get("X-Forwarded-For")
+	if forwarded != "" {
+		return forwarded
+	}
+	return r.RemoteAddr
+}
+
+func (s *Server) now() time.Time {
+	return s.clock.Now()
+}
+
+// elapsed is the time since the Server started serving.
+func (s *Server) elapsed() time.Duration {
+	s.markStarted()
+	return s.now().Sub(s.started)
+}
+
+// uptime returns the uptime in seconds and written in one of
+// uptimeFormats in the language of loc.
+func (s *Server) uptime(format string, loc *locale) (int, string) {
+	elapsed := s.elapsed()
+	return int(elapsed.Seconds()), formatUptime(elapsed, format, loc)
+}
+
+// ==================== HANDLERS ====================
+func (s *Server) mainHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet && r.URL.Path != "/" {
+		s.notFoundHandler(w, r)
+		return
+	}
+	s.infoHandler(w, r)
+}
+
+// infoHandler serves the service information on whatever path it is
+// mounted at.
+func (s *Server) infoHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, r, http.StatusMethodNotAllowed, "Only GET method is allowed for this endpoint")
+		return
+	}
+
+	if s.maintenance.reject(w, r) {
+		return
+	}
+
+	w.Header().Add("Vary", "Accept, Accept-Language")
+	format, ok := negotiateFormat(r)
+	if !ok {
+		writeNotAcceptable(w, r)
+		return
+	}
+	volatile, err := wantsVolatile(r)
+	if err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "volatile must be true or false")
+		return
+	}
+	loc := negotiateLocale(r)
+	opts := infoOptions{locale: loc}
+	if opts.uptimeFormat, ok = parseUptimeFormat(r); !ok {
+		writeJSONError(w, r, http.StatusBadRequest, loc.messagef(
+			"uptime_format must be one of %s", strings.Join(uptimeFormats, ", ")))
+		return
+	}
+	if opts.timezone, ok = requestTimezone(r, s.timezone); !ok {
+		writeJSONError(w, r, http.StatusBadRequest, loc.messagef(
+			"Unknown timezone %q, use an IANA name such as Europe/Moscow", r.URL.Query().Get("tz")))
+		return
+	}
+
+	sel, err := parseFieldSelection(r, reflect.TypeOf(ServiceInfo{}))
+	if err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.countVisit()
+	info := s.buildServiceInfo(r, sel, opts)
+
+	var body interface{} = responseBody(info, volatile)
+	if sel != nil {
+		body = sel.apply(toTree(info, treeOptions{skipVolatile: !volatile}))
+	}
+	w.Header().Set("Content-Language", loc.tag)
+	s.writeCacheable(w, r, format, body, cacheControlInfo)
+}
+
+// infoOptions are the per-request choices for the runtime section.
+type infoOptions struct {
+	uptimeFormat string
+	locale       *locale
+	timezone     *time.Location
+}
+
+// buildServiceInfo fills only the sections sel asks for; the others stay
+// zero. In particular the hostname lookup is skipped unless system is
+// selected.
+func (s *Server) buildServiceInfo(r *http.Request, sel *fieldSelection, opts infoOptions) ServiceInfo {
+	var info ServiceInfo
+
+	if sel.includes("service") {
+		info.Service = Service{
+			Name:        "devops-info-service",
+			Version:     version,
+			Description: "DevOps course info service",
+			Framework:   "Go net/http",
+		}
+	}
+	if sel.includes("deployment") {
+		info.Deployment = s.deployment
+	}
+	if sel.includes("system") {
+		info.System = System{
+			Hostname:        s.host(),
+			Platform:        runtime.GOOS,
+			PlatformVersion: runtime.Version(),
+			Architecture:    runtime.GOARCH,
+			CPUCount:        runtime.NumCPU(),
+			GoVersion:       runtime.Version(),
+		}
+	}
+	if sel.includes("runtime") {
+		uptimeSeconds, uptimeHuman := s.uptime(opts.uptimeFormat, opts.locale)
+		now := s.now().In(opts.timezone)
+		info.Runtime = Runtime{
+			UptimeSeconds: uptimeSeconds,
+			UptimeHuman:   uptimeHuman,
+			CurrentTime:   now.Format(time.RFC3339),
+			Timezone:      opts.timezone.String(),
+			UTCOffset:     utcOffset(now),
+		}
+		if t := s.timeSync; t != nil {
+			clockSync := t.snapshot()
+			info.Runtime.ClockSync = &clockSync
+		}
+	}
+	if sel.includes("request") {
+		info.Request = Request{
+			ClientIP:  getClientIP(r),
+			UserAgent: r.UserAgent(),
+			Method:    r.Method,
+			Path:      r.URL.Path,
+		}
+	}
+	if sel.includes("endpoints") {
+		info.Endpoints = s.endpointList()
+	}
+	return info
+}
+
+func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, r, http.StatusMethodNotAllowed, "Only GET method is allowed for this endpoint")
+		return
+	}
+
+	volatile, err := wantsVolatile(r)
+	if err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "volatile must be true or false")
+		return
+	}
+
+	health := s.health(negotiateLocale(r))
+	s.writeCacheable(w, r, responseFormats[0], responseBody(health, volatile), cacheControlHealth)
+}
+
+// health is what /health reports, with the reasons for a degraded status
+// in l's language. /ws health messages use it too.
+func (s *Server) health(l *locale) HealthResp {
+	uptimeSeconds, _ := s.uptime(uptimeFormats[0], english)
+
+	health := HealthResp{
+		Status:        "healthy",
+		Timestamp:     s.now().UTC().Format(time.RFC3339),
+		UptimeSeconds: uptimeSeconds,
+	}
+	if t := s.timeSync; t != nil && t.skewed() {
+		health.Status = "degraded"
+		health.Reasons = append(health.Reasons, l.messagef(
+			"Clock is off by more than %s from NTP server %s", t.maxSkew, t.server))
+	}
+	return health
+}
+
+func (s *Server) notFoundHandler(w http.ResponseWriter, r *http.Request) {
+	s.log.infof("http", "404 Not Found: %s %s", r.Method, r.URL.Path)
+	writeJSONError(w, r, http.StatusNotFound, "Endpoint does not exist")
+}
+
+// ==================== SERVER ====================
+func run(cfg Config) error {
+	log.SetFlags(log.LstdFlags)
+	s, err := NewServer(WithConfig(cfg))
+	if err != nil {
+		return err
+	}
+	s.log.infof("server", "Starting DevOps Info Service (Go) on %s:%s", cfg.Host, cfg.Port)
+
+	store, err := newVisitStore(cfg.DataDir)
+	if err != nil {
+		s.log.warnf("storage", "Visit counter disabled, data directory %s is not usable: %v", cfg.DataDir, err)
+	} else {
+		s.visits = store
+	}
+
+	if cfg.AccessLogFile != "" {
+		f, err := openRotatingFile(cfg.AccessLogFile, cfg.AccessLogMaxSize, cfg.AccessLogMaxBackups)
+		if err != nil {
+			return fmt.Errorf("access log: %w", err)
+		}
+		defer f.Close()
+		s.accessLogFile = f
+	}
+
+	if s.listener, err = listen(cfg); err != nil {
+		return err
+	}
+	s.log.infof("server", "Server is running on http://%s", cfg.addr())
+	s.log.infof("server", "Press Ctrl+C to stop")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	watchDebugSignal(ctx, s.log, cfg.LogDebugTimeout)
+	return s.Serve(ctx)
+}
+
+// Serve runs the server until ctx is cancelled, then shuts down
+// gracefully: readiness fails first and stays failed for
+// ShutdownDrainDelay while traffic still flows, so load balancers stop
+// routing here; then event streams and WebSockets are closed, and open
+// requests get up to ShutdownTimeout to finish.
+func (s *Server) Serve(ctx context.Context) error {
+	cfg := s.cfg
+	ln := s.listener
+	if ln == nil {
+		var err error
+		if ln, err = listen(cfg); err != nil {
+			return err
+		}
+	}
+	s.markStarted()
+	s.host()
+
+	if cfg.ChaosEnabled {
+		rules, _ := parseChaosRules(cfg.ChaosRules)
+		s.chaos = newChaosState(rules, s.chaosRand)
+		s.log.warnf("server", "Chaos mode is on with %d rules, faults can be injected", len(rules))
+	}
+	if cfg.Maintenance {
+		until, _ := parseMaintenanceUntil(cfg.MaintenanceUntil)
+		s.maintenance.Enable("config", cfg.MaintenanceMessage, until)
+	}
+	if path := maintenanceFilePath(cfg); path != "" {
+		go s.maintenance.watchFile(ctx, path, cfg.MaintenancePollInterval)
+	}
+	if cfg.ShedEnabled {
+		s.shed = newLoadShedder(cfg, s.now)
+	}
+	if cfg.NTPServer != "" {
+		s.timeSync = newTimeSyncer(cfg, s.now, s.log)
+		go s.timeSync.run(ctx)
+	}
+	s.events = newEventBroker(s)
+	go s.events.run(ctx)
+	s.diagnostics = newWSHub(s)
+
+	// Mirror log records to /ws clients subscribed to the logs topic.
+	defer s.log.addSink(s.diagnostics.publishLog)()
+
+	srv := newHTTPServer(cfg, s.Handler())
+	srv.RegisterOnShutdown(s.events.Close)
+	srv.RegisterOnShutdown(s.diagnostics.Close)
+	if s.logTail != nil {
+		srv.RegisterOnShutdown(s.logTail.Close)
+	}
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.Serve(ln) }()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	s.readiness.Set("shutdown", "Server is shutting down")
+	if cfg.ShutdownDrainDelay > 0 {
+		s.log.infof("server", "Shutting down, /ready fails for %s before new connections are refused", cfg.ShutdownDrainDelay)
+		drain := time.NewTimer(cfg.ShutdownDrainDelay)
+		select {
+		case <-drain.C:
+		case err := <-errCh:
+			drain.Stop()
+			return err
+		}
+	}
+	s.log.infof("server", "Shutting down, waiting up to %s for open requests", cfg.ShutdownTimeout)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("graceful shutdown: %w", err)
+	}
+	s.log.infof("server", "Server stopped")
+	return nil
+}
@@ -0,0 +1,193 @@
+package infoservice
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ==================== LOAD SHEDDING ====================
+
+// With a 200m CPU limit a burst piles up inside the process and latency
+// climbs long before Kubernetes adds pods. The shedder caps how many
+// requests are handled at once, lets a few more wait briefly, and turns the
+// rest away with a 503 so clients retry elsewhere. The cap adapts to
+// observed latency: it shrinks by a factor when requests get slower than
+// the target and grows by one per window of fast ones (AIMD).
+
+// shedBackoff is the factor the limit shrinks by when latency is too high.
+const shedBackoff = 0.9
+
+var (
+	errShedQueueFull = errors.New("wait queue is full")
+	errShedTimeout   = errors.New("waited too long")
+)
+
+var shedRejectedTotal = newCounterVec("devops_shed_rejected_total", "Requests rejected by load shedding, by reason.", "reason", "queue_full", "timeout")
+
+// shedGauges report s.shed, which is nil while load shedding is off.
+func (s *Server) shedGauges() []metric {
+	stat := func(field func(shedStats) int) func() (float64, bool) {
+		return func() (float64, bool) {
+			if s.shed == nil {
+				return 0, false
+			}
+			return float64(field(s.shed.stats())), true
+		}
+	}
+	return []metric{
+		&gaugeFunc{"devops_shed_limit", "Requests load shedding currently lets run at once.", stat(func(st shedStats) int { return st.Limit })},
+		&gaugeFunc{"devops_shed_in_flight", "Requests running under load shedding.", stat(func(st shedStats) int { return st.InFlight })},
+		&gaugeFunc{"devops_shed_queued", "Requests waiting for load shedding to let them run.", stat(func(st shedStats) int { return st.Queued })},
+	}
+}
+
+type loadShedder struct {
+	minLimit     float64
+	maxLimit     float64
+	target       time.Duration
+	queueSize    int
+	queueTimeout time.Duration
+	now          func() time.Time
+
+	mu           sync.Mutex
+	limit        float64
+	inFlight     int
+	queue        []chan struct{}
+	lastDecrease time.Time
+}
+
+// newLoadShedder starts at the maximum limit and only backs off once
+// latency, measured with now, shows the service is overloaded.
+func newLoadShedder(cfg Config, now func() time.Time) *loadShedder {
+	return &loadShedder{
+		minLimit:     float64(cfg.ShedMinLimit),
+		maxLimit:     float64(cfg.ShedMaxLimit),
+		target:       cfg.ShedTargetLatency,
+		queueSize:    cfg.ShedQueueSize,
+		queueTimeout: cfg.ShedQueueTimeout,
+		now:          now,
+		limit:        float64(cfg.ShedMaxLimit),
+	}
+}
+
+type shedStats struct {
+	Limit    int
+	InFlight int
+	Queued   int
+}
+
+func (s *loadShedder) stats() shedStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return shedStats{Limit: int(s.limit), InFlight: s.inFlight, Queued: len(s.queue)}
+}
+
+// acquire takes a slot, waiting in line for up to queueTimeout or until
+// ctx is done. Every nil return must be paired with a release.
+func (s *loadShedder) acquire(ctx context.Context) error {
+	s.mu.Lock()
+	if len(s.queue) == 0 && s.inFlight < int(s.limit) {
+		s.inFlight++
+		s.mu.Unlock()
+		return nil
+	}
+	if len(s.queue) >= s.queueSize {
+		s.mu.Unlock()
+		return errShedQueueFull
+	}
+	ready := make(chan struct{})
+	s.queue = append(s.queue, ready)
+	s.mu.Unlock()
+
+	timer := time.NewTimer(s.queueTimeout)
+	defer timer.Stop()
+	var err error
+	select {
+	case <-ready:
+		return nil
+	case <-timer.C:
+		err = errShedTimeout
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, ch := range s.queue {
+		if ch == ready {
+			s.queue = append(s.queue[:i], s.queue[i+1:]...)
+			return err
+		}
+	}
+	// release handed over a slot just as the wait ended; take it.
+	return nil
+}
+
+// release frees a slot, adjusts the limit by the request's latency and
+// lets waiting requests in.
+func (s *loadShedder) release(latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inFlight--
+
+	if latency > s.target {
+		// Requests started together finish slow together; back off once
+		// per target interval rather than once per request.
+		if now := s.now(); now.Sub(s.lastDecrease) >= s.target {
+			s.limit = max(s.minLimit, s.limit*shedBackoff)
+			s.lastDecrease = now
+		}
+	} else if float64(s.inFlight+1) >= s.limit/2 {
+		// Only grow a limit that is actually in use.
+		s.limit = min(s.maxLimit, s.limit+1/s.limit)
+	}
+
+	for len(s.queue) > 0 && s.inFlight < int(s.limit) {
+		ch := s.queue[0]
+		s.queue = s.queue[1:]
+		s.inFlight++
+		close(ch)
+	}
+}
+
+// retryAfter is the Retry-After value sent with a 503: the queue timeout
+// in whole seconds, at least one.
+func (s *loadShedder) retryAfter() string {
+	return strconv.Itoa(max(1, int(math.Ceil(s.queueTimeout.Seconds()))))
+}
+
+// shedLoad runs next under the load shedder's limit.
+func (s *Server) shedLoad(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sh := s.shed
+		if sh == nil {
+			next(w, r)
+			return
+		}
+		if err := sh.acquire(r.Context()); err != nil {
+			if r.Context().Err() != nil {
+				// The client gave up while waiting; nobody reads an answer.
+				return
+			}
+			reason := "timeout"
+			if errors.Is(err, errShedQueueFull) {
+				reason = "queue_full"
+			}
+			shedRejectedTotal.inc(reason)
+			s.log.log(levelDebug, "http", map[string]interface{}{
+				"request_id": requestIDFrom(r.Context()),
+			}, "Load shedding: rejected %s %s, %v", r.Method, r.URL.Path, err)
+			w.Header().Set("Retry-After", sh.retryAfter())
+			writeJSONError(w, r, http.StatusServiceUnavailable, "Server is overloaded, retry later")
+			return
+		}
+		start := sh.now()
+		defer func() { sh.release(sh.now().Sub(start)) }()
+		next(w, r)
+	}
+}
@@ -0,0 +1,161 @@
+package infoservice
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withShedder turns load shedding on for srv with the given limits.
+func withShedder(srv *Server, minLimit, maxLimit, queueSize int, queueTimeout time.Duration) *loadShedder {
+	cfg := DefaultConfig()
+	cfg.ShedMinLimit, cfg.ShedMaxLimit = minLimit, maxLimit
+	cfg.ShedQueueSize, cfg.ShedQueueTimeout = queueSize, queueTimeout
+	srv.shed = newLoadShedder(cfg, srv.now)
+	return srv.shed
+}
+
+func TestLoadShedder_Queue(t *testing.T) {
+	s := withShedder(newTestServer(t), 1, 1, 1, time.Minute)
+	ctx := context.Background()
+
+	if err := s.acquire(ctx); err != nil {
+		t.Fatalf("first acquire: %v", err)
+	}
+	queued := make(chan error, 1)
+	go func() { queued <- s.acquire(ctx) }()
+	for s.stats().Queued == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if err := s.acquire(ctx); !errors.Is(err, errShedQueueFull) {
+		t.Errorf("third acquire = %v, want queue full", err)
+	}
+
+	s.release(0)
+	if err := <-queued; err != nil {
+		t.Errorf("queued acquire = %v, want the released slot", err)
+	}
+	if got := s.stats(); got != (shedStats{Limit: 1, InFlight: 1}) {
+		t.Errorf("stats = %+v", got)
+	}
+}
+
+func TestLoadShedder_Deadline(t *testing.T) {
+	s := withShedder(newTestServer(t), 1, 1, 5, 20*time.Millisecond)
+	if err := s.acquire(context.Background()); err != nil {
+		t.Fatalf("first acquire: %v", err)
+	}
+	if err := s.acquire(context.Background()); !errors.Is(err, errShedTimeout) {
+		t.Errorf("acquire = %v, want timeout", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.acquire(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled acquire = %v", err)
+	}
+	if q := s.stats().Queued; q != 0 {
+		t.Errorf("queued = %d, want 0 after giving up", q)
+	}
+}
+
+func TestLoadShedder_AdaptsToLatency(t *testing.T) {
+	clock := newFakeClock(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	s := withShedder(newTestServer(t, WithClock(clock)), 2, 10, 0, time.Second)
+	slow := 2 * s.target
+
+	take := func(n int) {
+		for i := 0; i < n; i++ {
+			if err := s.acquire(context.Background()); err != nil {
+				t.Fatalf("acquire: %v", err)
+			}
+		}
+	}
+
+	// A burst of slow requests backs off once, not once per request.
+	take(5)
+	for i := 0; i < 5; i++ {
+		s.release(slow)
+	}
+	if got := s.stats().Limit; got != 9 {
+		t.Errorf("after one slow burst limit = %d, want 9", got)
+	}
+
+	for i := 0; i < 30; i++ {
+		clock.Advance(time.Second)
+		take(1)
+		s.release(slow)
+	}
+	if got := s.stats().Limit; got != 2 {
+		t.Errorf("limit = %d, want the minimum 2", got)
+	}
+
+	// Fast requests that use the limit grow it by about one per window.
+	for i := 0; i < 10; i++ {
+		take(2)
+		s.release(0)
+		s.release(0)
+	}
+	if got := s.stats().Limit; got < 4 || got > 10 {
+		t.Errorf("after fast requests limit = %d, want it grown", got)
+	}
+}
+
+func TestShedLoad_Rejects(t *testing.T) {
+	srv := newTestServer(t)
+	s := withShedder(srv, 1, 1, 0, 1500*time.Millisecond)
+	before := shedRejectedTotal.get("queue_full")
+
+	h := srv.shedLoad(okHandler)
+	s.acquire(context.Background())
+	w := serveGet(h, "/", nil)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After = %q, want 2", got)
+	}
+	if got := shedRejectedTotal.get("queue_full"); got != before+1 {
+		t.Errorf("rejected = %d, want %d", got, before+1)
+	}
+
+	s.release(0)
+	if w := serveGet(h, "/", nil); w.Code != http.StatusOK {
+		t.Errorf("after release status = %d, want 200", w.Code)
+	}
+	if got := s.stats().InFlight; got != 0 {
+		t.Errorf("in flight = %d, want 0", got)
+	}
+}
+
+func TestNewHandler_ShedsAllButPriorityRoutes(t *testing.T) {
+	srv := newTestServer(t)
+	s := withShedder(srv, 1, 1, 0, time.Second)
+	s.acquire(context.Background())
+	h := srv.Handler()
+
+	for path, want := range map[string]int{
+		"/":             http.StatusServiceUnavailable,
+		"/openapi.json": http.StatusServiceUnavailable,
+		"/health":       http.StatusOK,
+		"/ready":        http.StatusOK,
+		"/metrics":      http.StatusOK,
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != want {
+			t.Errorf("%s: status = %d, want %d", path, w.Code, want)
+		}
+	}
+
+	w := serveGet(srv.metricsHandler, "/metrics", nil)
+	for _, want := range []string{"devops_shed_limit{", "devops_shed_in_flight{", `devops_shed_rejected_total{`} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("metrics lack %s", want)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+//go:build !windows
+
+package infoservice
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// watchDebugSignal toggles temporary debug logging of l on every SIGUSR1
+// until ctx is done.
+func watchDebugSignal(ctx context.Context, l *logger, timeout time.Duration) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGUSR1)
+	go func() {
+		defer signal.Stop(ch)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ch:
+				l.toggleDebugLogging(timeout)
+			}
+		}
+	}()
+}
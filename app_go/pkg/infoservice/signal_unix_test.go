@@ -0,0 +1,46 @@
+//go:build !windows
+
+package infoservice
+
+import (
+	"context"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestWatchDebugSignal(t *testing.T) {
+	lg := newLogger(discardLogs, nil)
+	l := lg.levels
+	records := make(chan logRecord, 16)
+	defer lg.addSink(func(rec logRecord) { records <- rec })()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watchDebugSignal(ctx, lg, time.Minute)
+
+	// Wait for the log line rather than polling the state, so the watcher
+	// is done toggling before the test looks.
+	toggle := func(want string) {
+		t.Helper()
+		syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+		for {
+			select {
+			case rec := <-records:
+				if strings.Contains(rec.Message, want) {
+					return
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatalf("no %q log line after SIGUSR1", want)
+			}
+		}
+	}
+	toggle("Temporary debug logging on for 1m0s")
+	if !l.enabled(levelDebug, "http") {
+		t.Error("debug logging is off after the first SIGUSR1")
+	}
+	toggle("Temporary debug logging turned off")
+	if l.enabled(levelDebug, "http") {
+		t.Error("debug logging is still on after the second SIGUSR1")
+	}
+}
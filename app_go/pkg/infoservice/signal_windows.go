@@ -0,0 +1,10 @@
+package infoservice
+
+import (
+	"context"
+	"time"
+)
+
+// watchDebugSignal does nothing: Windows has no SIGUSR1. Use
+// PUT /admin/log-level instead.
+func watchDebugSignal(ctx context.Context, l *logger, timeout time.Duration) {}
@@ -0,0 +1,172 @@
+package infoservice
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ==================== GENERIC RESPONSE TREE ====================
+
+// Responses are structs, but some features need to reshape them per request
+// (dropping volatile fields, picking a subset of fields). toTree converts a
+// value into a tree of *orderedMap, []interface{} and scalars that keeps the
+// field order of the structs, so every renderer produces the same layout as
+// it would for the struct itself.
+
+// orderedMap is a JSON object that remembers insertion order.
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newOrderedMap() *orderedMap {
+	return &orderedMap{values: make(map[string]interface{})}
+}
+
+func (m *orderedMap) Set(key string, value interface{}) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+func (m *orderedMap) Get(key string) (interface{}, bool) {
+	v, ok := m.values[key]
+	return v, ok
+}
+
+func (m *orderedMap) Delete(key string) {
+	if _, ok := m.values[key]; !ok {
+		return
+	}
+	delete(m.values, key)
+	for i, k := range m.keys {
+		if k == key {
+			m.keys = append(m.keys[:i], m.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+func (m *orderedMap) Keys() []string {
+	return m.keys
+}
+
+func (m *orderedMap) Len() int {
+	return len(m.keys)
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		val, err := json.Marshal(m.values[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// treeOptions controls what toTree keeps.
+type treeOptions struct {
+	// skipVolatile drops struct fields tagged volatile:"true", i.e. the
+	// values that change on every request such as timestamps and uptime.
+	skipVolatile bool
+}
+
+// toTree converts v to the generic tree. Struct fields are named after their
+// json tags; maps get their keys sorted so the output is deterministic.
+func toTree(v interface{}, opts treeOptions) interface{} {
+	if _, ok := v.(*orderedMap); ok {
+		return v
+	}
+	return treeValue(reflect.ValueOf(v), opts)
+}
+
+// isEmptyValue reports whether encoding/json leaves v out under
+// omitempty.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Struct:
+		return false
+	}
+	return v.IsZero()
+}
+
+func treeValue(v reflect.Value, opts treeOptions) interface{} {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		if m, ok := v.Interface().(*orderedMap); ok {
+			return m
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		m := newOrderedMap()
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			if opts.skipVolatile && f.Tag.Get("volatile") == "true" {
+				continue
+			}
+			tag := strings.Split(f.Tag.Get("json"), ",")
+			name := tag[0]
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			// Leave out what encoding/json would.
+			if len(tag) > 1 && tag[1] == "omitempty" && isEmptyValue(v.Field(i)) {
+				continue
+			}
+			m.Set(name, treeValue(v.Field(i), opts))
+		}
+		return m
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		m := newOrderedMap()
+		for _, k := range keys {
+			m.Set(k.String(), treeValue(v.MapIndex(k), opts))
+		}
+		return m
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return []interface{}{}
+		}
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			out[i] = treeValue(v.Index(i), opts)
+		}
+		return out
+	case reflect.Invalid:
+		return nil
+	}
+	return v.Interface()
+}
@@ -0,0 +1,71 @@
+package infoservice
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestToTree_KeepsFieldOrder(t *testing.T) {
+	v := struct {
+		Zeta  string            `json:"zeta"`
+		Alpha int               `json:"alpha" volatile:"true"`
+		List  []string          `json:"list"`
+		Map   map[string]string `json:"map"`
+		Skip  string            `json:"-"`
+	}{Zeta: "z", Alpha: 1, Map: map[string]string{"b": "2", "a": "1"}}
+
+	full, _ := json.Marshal(toTree(v, treeOptions{}))
+	if string(full) != `{"zeta":"z","alpha":1,"list":[],"map":{"a":"1","b":"2"}}` {
+		t.Errorf("toTree JSON = %s", full)
+	}
+
+	stripped, _ := json.Marshal(toTree(v, treeOptions{skipVolatile: true}))
+	if string(stripped) != `{"zeta":"z","list":[],"map":{"a":"1","b":"2"}}` {
+		t.Errorf("toTree without volatile = %s", stripped)
+	}
+}
+
+func TestToTree_OmitEmpty(t *testing.T) {
+	type inner struct {
+		N int `json:"n"`
+	}
+	for _, tt := range []struct {
+		v    interface{}
+		want string
+	}{
+		{struct {
+			S   string   `json:"s,omitempty"`
+			P   *inner   `json:"p,omitempty"`
+			L   []string `json:"l,omitempty"`
+			In  inner    `json:"in,omitempty"`
+			Any string   `json:"any"`
+		}{}, `{"in":{"n":0},"any":""}`},
+		{struct {
+			S string `json:"s,omitempty"`
+			P *inner `json:"p,omitempty"`
+		}{"x", &inner{1}}, `{"s":"x","p":{"n":1}}`},
+	} {
+		tree, _ := json.Marshal(toTree(tt.v, treeOptions{}))
+		plain, _ := json.Marshal(tt.v)
+		if string(tree) != tt.want || string(plain) != tt.want {
+			t.Errorf("toTree = %s, json = %s, want %s", tree, plain, tt.want)
+		}
+	}
+}
+
+func TestOrderedMap(t *testing.T) {
+	m := newOrderedMap()
+	m.Set("b", 1)
+	m.Set("a", 2)
+	m.Set("b", 3)
+	m.Delete("missing")
+
+	if b, _ := json.Marshal(m); string(b) != `{"b":3,"a":2}` {
+		t.Errorf("MarshalJSON = %s", b)
+	}
+
+	m.Delete("b")
+	if m.Len() != 1 || m.Keys()[0] != "a" {
+		t.Errorf("after Delete keys = %v", m.Keys())
+	}
+}
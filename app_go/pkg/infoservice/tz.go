@@ -0,0 +1,46 @@
+package infoservice
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	// The distroless image has no /usr/share/zoneinfo; carry the zone
+	// database in the binary so TIMEZONE and ?tz= work everywhere.
+	_ "time/tzdata"
+)
+
+// ==================== TIMEZONES ====================
+
+// loadTimezone resolves an IANA zone name such as Europe/Moscow; empty
+// means UTC. "Local" is refused: it is whatever zone the host happens to
+// be set to, which is how app_go and app_python used to disagree.
+func loadTimezone(name string) (*time.Location, error) {
+	if name == "Local" {
+		return nil, fmt.Errorf("unknown timezone %q", name)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q", name)
+	}
+	return loc, nil
+}
+
+// requestTimezone returns the zone named by ?tz=, or def without one. ok
+// is false when ?tz= names no known zone.
+func requestTimezone(r *http.Request, def *time.Location) (*time.Location, bool) {
+	name := r.URL.Query().Get("tz")
+	if name == "" {
+		return def, true
+	}
+	loc, err := loadTimezone(name)
+	if err != nil {
+		return nil, false
+	}
+	return loc, true
+}
+
+// utcOffset writes the offset of t from UTC, e.g. +03:00.
+func utcOffset(t time.Time) string {
+	return t.Format("-07:00")
+}
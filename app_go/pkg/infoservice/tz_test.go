@@ -0,0 +1,94 @@
+package infoservice
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadTimezone(t *testing.T) {
+	for _, tt := range []struct {
+		name, want string
+		ok         bool
+	}{
+		{"", "UTC", true},
+		{"UTC", "UTC", true},
+		{"Europe/Moscow", "Europe/Moscow", true},
+		{"America/New_York", "America/New_York", true},
+		{"Local", "", false},
+		{"Europe/Atlantis", "", false},
+		{"../etc/passwd", "", false},
+	} {
+		loc, err := loadTimezone(tt.name)
+		if (err == nil) != tt.ok {
+			t.Errorf("loadTimezone(%q) error = %v, want ok %v", tt.name, err, tt.ok)
+			continue
+		}
+		if tt.ok && loc.String() != tt.want {
+			t.Errorf("loadTimezone(%q) = %s, want %s", tt.name, loc, tt.want)
+		}
+	}
+}
+
+func TestMainHandler_Timezone(t *testing.T) {
+	winter := time.Date(2026, 1, 15, 9, 30, 0, 0, time.UTC)
+	summer := time.Date(2026, 7, 15, 9, 30, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		name                      string
+		now                       time.Time
+		config, query             string
+		zone, offset, currentTime string
+	}{
+		{"default", winter, "UTC", "", "UTC", "+00:00", "2026-01-15T09:30:00Z"},
+		{"configured", winter, "Europe/Moscow", "", "Europe/Moscow", "+03:00", "2026-01-15T12:30:00+03:00"},
+		{"per request", winter, "UTC", "?tz=Asia/Kolkata", "Asia/Kolkata", "+05:30", "2026-01-15T15:00:00+05:30"},
+		{"request overrides config", winter, "Europe/Moscow", "?tz=UTC", "UTC", "+00:00", "2026-01-15T09:30:00Z"},
+		{"standard time", winter, "UTC", "?tz=America/New_York", "America/New_York", "-05:00", "2026-01-15T04:30:00-05:00"},
+		{"daylight saving time", summer, "UTC", "?tz=America/New_York", "America/New_York", "-04:00", "2026-07-15T05:30:00-04:00"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Timezone = tt.config
+			s := newTestServer(t, WithConfig(cfg), WithClock(newFakeClock(tt.now)))
+
+			w := serveGet(s.mainHandler, "/"+tt.query, nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, body %s", w.Code, w.Body)
+			}
+			var info ServiceInfo
+			if err := json.Unmarshal(w.Body.Bytes(), &info); err != nil {
+				t.Fatal(err)
+			}
+			got := info.Runtime
+			if got.Timezone != tt.zone || got.UTCOffset != tt.offset || got.CurrentTime != tt.currentTime {
+				t.Errorf("runtime = %s %s %s, want %s %s %s", got.Timezone, got.UTCOffset, got.CurrentTime, tt.zone, tt.offset, tt.currentTime)
+			}
+		})
+	}
+}
+
+func TestMainHandler_UnknownTimezone(t *testing.T) {
+	s := newTestServer(t)
+	for _, tz := range []string{"Mars/Olympus_Mons", "Local", "%2F"} {
+		w := serveGet(s.mainHandler, "/?lang=ru&tz="+tz, nil)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("tz=%s: status = %d, want 400", tz, w.Code)
+			continue
+		}
+		var body ErrorResp
+		json.Unmarshal(w.Body.Bytes(), &body)
+		if !strings.HasPrefix(body.Message, "Неизвестный часовой пояс") {
+			t.Errorf("tz=%s: message = %q, want it in Russian", tz, body.Message)
+		}
+	}
+}
+
+func TestNewServer_RejectsUnknownTimezone(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Timezone = "Nowhere/Special"
+	if _, err := NewServer(WithConfig(cfg)); err == nil {
+		t.Error("expected an error for an unknown TIMEZONE")
+	}
+}
@@ -0,0 +1,105 @@
+package infoservice
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ==================== CLOCK AND UPTIME ====================
+
+// Clock tells the current time. A Server uses the system clock unless
+// WithClock gives it another, such as a fake one in tests.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts a function such as time.Now to a Clock.
+type ClockFunc func() time.Time
+
+func (f ClockFunc) Now() time.Time { return f() }
+
+// uptimeFormats are the values of ?uptime_format=. The first is the
+// default and what app_python reports.
+var uptimeFormats = []string{"human", "full", "iso8601", "compact"}
+
+// parseUptimeFormat reads the ?uptime_format= query parameter.
+func parseUptimeFormat(r *http.Request) (string, bool) {
+	raw := r.URL.Query().Get("uptime_format")
+	if raw == "" {
+		return uptimeFormats[0], true
+	}
+	for _, f := range uptimeFormats {
+		if raw == f {
+			return f, true
+		}
+	}
+	return "", false
+}
+
+// formatUptime writes d, in whole seconds, in one of uptimeFormats and,
+// for human and full, in the language of loc:
+//
+//	human    26 hours, 3 minutes
+//	full     1 day, 2 hours, 3 minutes, 4 seconds
+//	iso8601  P1DT2H3M4S
+//	compact  1d2h3m4s
+func formatUptime(d time.Duration, format string, loc *locale) string {
+	total := int64(max(d, 0) / time.Second)
+	units := []struct {
+		n     int64
+		short string
+		unit  string
+	}{
+		{total / 86400, "d", "day"},
+		{total % 86400 / 3600, "h", "hour"},
+		{total % 3600 / 60, "m", "minute"},
+		{total % 60, "s", "second"},
+	}
+
+	var b strings.Builder
+	switch format {
+	case "full":
+		// Leading zero units are left out, the ones after the first are not.
+		for i, u := range units {
+			if b.Len() == 0 && u.n == 0 && i < len(units)-1 {
+				continue
+			}
+			if b.Len() > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(loc.unit(u.n, u.unit))
+		}
+	case "iso8601":
+		b.WriteString("P")
+		for i, u := range units {
+			if i == 1 && total%86400 != 0 {
+				b.WriteString("T")
+			}
+			if u.n != 0 {
+				fmt.Fprintf(&b, "%d%s", u.n, strings.ToUpper(u.short))
+			}
+		}
+		if total == 0 {
+			b.WriteString("T0S")
+		}
+	case "compact":
+		for _, u := range units {
+			if u.n != 0 {
+				fmt.Fprintf(&b, "%d%s", u.n, u.short)
+			}
+		}
+		if total == 0 {
+			b.WriteString("0s")
+		}
+	default:
+		if loc == english {
+			// Always plural, exactly as app_python writes it.
+			fmt.Fprintf(&b, "%d hours, %d minutes", total/3600, total%3600/60)
+		} else {
+			b.WriteString(loc.unit(total/3600, "hour") + ", " + loc.unit(total%3600/60, "minute"))
+		}
+	}
+	return b.String()
+}
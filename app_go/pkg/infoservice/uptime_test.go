@@ -0,0 +1,103 @@
+package infoservice
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock that only moves when told to.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestFormatUptime(t *testing.T) {
+	const day = 24 * time.Hour
+	for _, tt := range []struct {
+		uptime                        time.Duration
+		human, full, iso8601, compact string
+	}{
+		{0, "0 hours, 0 minutes", "0 seconds", "PT0S", "0s"},
+		{time.Second, "0 hours, 0 minutes", "1 second", "PT1S", "1s"},
+		{59*time.Second + 900*time.Millisecond, "0 hours, 0 minutes", "59 seconds", "PT59S", "59s"},
+		{61 * time.Second, "0 hours, 1 minutes", "1 minute, 1 second", "PT1M1S", "1m1s"},
+		{2 * time.Hour, "2 hours, 0 minutes", "2 hours, 0 minutes, 0 seconds", "PT2H", "2h"},
+		{day, "24 hours, 0 minutes", "1 day, 0 hours, 0 minutes, 0 seconds", "P1D", "1d"},
+		{3*day + 4*time.Hour, "76 hours, 0 minutes", "3 days, 4 hours, 0 minutes, 0 seconds", "P3DT4H", "3d4h"},
+		{day + 2*time.Hour + 3*time.Minute + 4*time.Second, "26 hours, 3 minutes", "1 day, 2 hours, 3 minutes, 4 seconds", "P1DT2H3M4S", "1d2h3m4s"},
+		{-time.Minute, "0 hours, 0 minutes", "0 seconds", "PT0S", "0s"},
+	} {
+		for format, want := range map[string]string{"human": tt.human, "full": tt.full, "iso8601": tt.iso8601, "compact": tt.compact} {
+			if got := formatUptime(tt.uptime, format, english); got != want {
+				t.Errorf("formatUptime(%v, %s) = %q, want %q", tt.uptime, format, got, want)
+			}
+		}
+	}
+}
+
+func TestServer_StartsClockWhenServing(t *testing.T) {
+	t.Parallel()
+	clock := newFakeClock(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	s := newTestServer(t, WithClock(clock))
+
+	// Time before the first request does not count as uptime.
+	clock.Advance(time.Hour)
+	if got, _ := s.uptime("human", english); got != 0 {
+		t.Errorf("uptime at the first request = %d, want 0", got)
+	}
+	clock.Advance(90 * time.Second)
+	if got, human := s.uptime("compact", english); got != 90 || human != "1m30s" {
+		t.Errorf("uptime = %d %q, want 90 1m30s", got, human)
+	}
+}
+
+func TestMainHandler_UptimeFormat(t *testing.T) {
+	s := withClock(t, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), 3*24*time.Hour+4*time.Hour+5*time.Second)
+	for _, tt := range []struct {
+		query string
+		want  string
+		code  int
+	}{
+		{"", "76 hours, 0 minutes", http.StatusOK},
+		{"?uptime_format=human", "76 hours, 0 minutes", http.StatusOK},
+		{"?uptime_format=full", "3 days, 4 hours, 0 minutes, 5 seconds", http.StatusOK},
+		{"?uptime_format=iso8601", "P3DT4H5S", http.StatusOK},
+		{"?uptime_format=compact", "3d4h5s", http.StatusOK},
+		{"?uptime_format=weeks", "", http.StatusBadRequest},
+	} {
+		w := serveGet(s.mainHandler, "/"+tt.query, nil)
+		if w.Code != tt.code {
+			t.Errorf("%s: status = %d, want %d", tt.query, w.Code, tt.code)
+			continue
+		}
+		if tt.code != http.StatusOK {
+			continue
+		}
+		var info ServiceInfo
+		if err := json.Unmarshal(w.Body.Bytes(), &info); err != nil {
+			t.Fatal(err)
+		}
+		if info.Runtime.UptimeHuman != tt.want || info.Runtime.UptimeSeconds != 273605 {
+			t.Errorf("%s: uptime = %d %q, want 273605 %q", tt.query, info.Runtime.UptimeSeconds, info.Runtime.UptimeHuman, tt.want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package infoservice
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ==================== VISITS ====================
+
+// visitStore keeps the visit counter in DATA_DIR/visits as a plain decimal
+// number, the same format app_python uses, so both services can share the
+// volume the Helm chart mounts at /app/data.
+type visitStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+func newVisitStore(dir string) (*visitStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &visitStore{path: filepath.Join(dir, "visits")}, nil
+}
+
+func (s *visitStore) Count() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read()
+}
+
+// Increment adds one visit and returns the new total.
+func (s *visitStore) Increment() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, err := s.read()
+	if err != nil {
+		return 0, err
+	}
+	n++
+
+	// Write to a temp file and rename, so a crash never leaves a
+	// half-written counter behind.
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.Itoa(n)), 0o644); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// read returns 0 for a missing or unreadable counter, like app_python.
+func (s *visitStore) read() (int, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, nil
+	}
+	return n, nil
+}
+
+// countVisit is called for every GET /. Storage errors are logged, not
+// returned: a broken volume must not take the info endpoint down.
+func (s *Server) countVisit() {
+	if s.visits == nil {
+		return
+	}
+	if _, err := s.visits.Increment(); err != nil {
+		s.log.errorf("storage", "Error counting visit: %v", err)
+	}
+}
+
+func (s *Server) visitsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, r, http.StatusMethodNotAllowed, "Only GET method is allowed for this endpoint")
+		return
+	}
+	if s.visits == nil {
+		writeJSONError(w, r, http.StatusServiceUnavailable, "Visit counter is not available")
+		return
+	}
+
+	n, err := s.visits.Count()
+	if err != nil {
+		s.log.errorf("storage", "Error reading visits: %v", err)
+		writeJSONError(w, r, http.StatusInternalServerError, "Failed to read visit counter")
+		return
+	}
+	s.writeFormatted(w, r, responseFormats[0], http.StatusOK, VisitsResp{Visits: n})
+}
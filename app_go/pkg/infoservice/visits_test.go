@@ -0,0 +1,108 @@
+package infoservice
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// withVisitStore points the visit counter at a fresh temp directory for the
+// duration of the test.
+func withVisitStore(t *testing.T, s *Server) *visitStore {
+	t.Helper()
+	store, err := newVisitStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("newVisitStore: %v", err)
+	}
+	s.visits = store
+	return store
+}
+
+func TestVisitStore_IncrementPersists(t *testing.T) {
+	dir := t.TempDir()
+	store, err := newVisitStore(filepath.Join(dir, "nested"))
+	if err != nil {
+		t.Fatalf("newVisitStore: %v", err)
+	}
+
+	if n, _ := store.Count(); n != 0 {
+		t.Errorf("initial count = %d, want 0", n)
+	}
+	for i := 1; i <= 3; i++ {
+		if n, err := store.Increment(); err != nil || n != i {
+			t.Fatalf("Increment = (%d, %v), want %d", n, err, i)
+		}
+	}
+
+	// Same file format as app_python: a bare decimal number.
+	data, err := os.ReadFile(filepath.Join(dir, "nested", "visits"))
+	if err != nil || string(data) != "3" {
+		t.Errorf("visits file = %q (%v), want \"3\"", data, err)
+	}
+
+	reopened, _ := newVisitStore(filepath.Join(dir, "nested"))
+	if n, _ := reopened.Count(); n != 3 {
+		t.Errorf("count after reopen = %d, want 3", n)
+	}
+}
+
+func TestVisitStore_CorruptFileCountsFromZero(t *testing.T) {
+	store := withVisitStore(t, newTestServer(t))
+	os.WriteFile(store.path, []byte("garbage"), 0o644)
+
+	if n, err := store.Increment(); err != nil || n != 1 {
+		t.Errorf("Increment = (%d, %v), want 1", n, err)
+	}
+}
+
+func TestVisitStore_Concurrent(t *testing.T) {
+	store := withVisitStore(t, newTestServer(t))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			store.Increment()
+		}()
+	}
+	wg.Wait()
+
+	if n, _ := store.Count(); n != 20 {
+		t.Errorf("count = %d, want 20", n)
+	}
+}
+
+func TestVisitsHandler(t *testing.T) {
+	s := newTestServer(t)
+	withVisitStore(t, s)
+
+	serveGet(s.mainHandler, "/", nil)
+	serveGet(s.mainHandler, "/", nil)
+	serveGet(s.healthHandler, "/health", nil)
+
+	w := serveGet(s.visitsHandler, "/visits", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	var resp VisitsResp
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if resp.Visits != 2 {
+		t.Errorf("visits = %d, want 2 (only GET / counts)", resp.Visits)
+	}
+}
+
+func TestVisitsHandler_NoStore(t *testing.T) {
+	s := newTestServer(t)
+	if w := serveGet(s.visitsHandler, "/visits", nil); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", w.Code)
+	}
+	if w := serveGet(s.mainHandler, "/", nil); w.Code != http.StatusOK {
+		t.Errorf("info status without a store = %d, want 200", w.Code)
+	}
+}
@@ -0,0 +1,206 @@
+package infoservice
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ==================== WEBSOCKET PROTOCOL (RFC 6455) ====================
+
+// Only what /ws needs: the server side of the handshake, frames without
+// extensions, and enough of a client side for tests.
+
+const wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+const (
+	wsOpContinuation byte = 0x0
+	wsOpText         byte = 0x1
+	wsOpBinary       byte = 0x2
+	wsOpClose        byte = 0x8
+	wsOpPing         byte = 0x9
+	wsOpPong         byte = 0xA
+)
+
+// Close codes used by the server.
+const (
+	wsCloseNormal          = 1000
+	wsCloseGoingAway       = 1001
+	wsCloseProtocolError   = 1002
+	wsCloseUnsupportedData = 1003
+	wsCloseInvalidPayload  = 1007
+	wsClosePolicyViolation = 1008
+	wsCloseTooBig          = 1009
+)
+
+// wsMaxMessageBytes caps a client message, fragments included.
+const wsMaxMessageBytes = 64 << 10
+
+type wsFrame struct {
+	fin     bool
+	opcode  byte
+	masked  bool
+	payload []byte
+}
+
+// wsCloseError is a protocol violation; the connection is closed with code.
+type wsCloseError struct {
+	code   int
+	reason string
+}
+
+func (e *wsCloseError) Error() string {
+	return fmt.Sprintf("websocket: %s (close %d)", e.reason, e.code)
+}
+
+// wsAcceptKey computes Sec-WebSocket-Accept for a Sec-WebSocket-Key.
+func wsAcceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + wsGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func headerContainsToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, part := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkWSHandshake validates an upgrade request and returns the client key.
+func checkWSHandshake(r *http.Request) (string, error) {
+	if r.Method != http.MethodGet {
+		return "", errors.New("websocket handshake must use GET")
+	}
+	if !headerContainsToken(r.Header, "Connection", "upgrade") || !headerContainsToken(r.Header, "Upgrade", "websocket") {
+		return "", errors.New("expected a websocket upgrade request")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		return "", errors.New("unsupported Sec-WebSocket-Version, want 13")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return "", errors.New("invalid Sec-WebSocket-Key")
+	}
+	return key, nil
+}
+
+// readWSFrame reads one frame. Payloads larger than maxSize are refused
+// before they are read.
+func readWSFrame(r *bufio.Reader, maxSize int) (wsFrame, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return wsFrame{}, err
+	}
+	f := wsFrame{
+		fin:    head[0]&0x80 != 0,
+		opcode: head[0] & 0x0F,
+		masked: head[1]&0x80 != 0,
+	}
+	if head[0]&0x70 != 0 {
+		return f, &wsCloseError{wsCloseProtocolError, "reserved bits set"}
+	}
+
+	length := uint64(head[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return f, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return f, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+
+	if f.opcode >= wsOpClose && (!f.fin || length > 125) {
+		return f, &wsCloseError{wsCloseProtocolError, "invalid control frame"}
+	}
+	if length > uint64(maxSize) {
+		return f, &wsCloseError{wsCloseTooBig, "message too big"}
+	}
+
+	var mask [4]byte
+	if f.masked {
+		if _, err := io.ReadFull(r, mask[:]); err != nil {
+			return f, err
+		}
+	}
+	f.payload = make([]byte, length)
+	if _, err := io.ReadFull(r, f.payload); err != nil {
+		return f, err
+	}
+	if f.masked {
+		for i := range f.payload {
+			f.payload[i] ^= mask[i%4]
+		}
+	}
+	return f, nil
+}
+
+// writeWSFrame writes one final frame. Servers send unmasked frames;
+// clients must mask.
+func writeWSFrame(w io.Writer, opcode byte, payload []byte, mask bool) error {
+	buf := make([]byte, 0, 14+len(payload))
+	buf = append(buf, 0x80|opcode)
+
+	var maskBit byte
+	if mask {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n <= 125:
+		buf = append(buf, maskBit|byte(n))
+	case n <= 0xFFFF:
+		buf = append(buf, maskBit|126)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(n))
+	default:
+		buf = append(buf, maskBit|127)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(n))
+	}
+
+	if !mask {
+		buf = append(buf, payload...)
+	} else {
+		var key [4]byte
+		if _, err := rand.Read(key[:]); err != nil {
+			return err
+		}
+		buf = append(buf, key[:]...)
+		for i, b := range payload {
+			buf = append(buf, b^key[i%4])
+		}
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+// wsClosePayload encodes a close frame body: 2-byte code, then the reason.
+func wsClosePayload(code int, reason string) []byte {
+	if len(reason) > 123 {
+		reason = reason[:123]
+	}
+	return append(binary.BigEndian.AppendUint16(nil, uint16(code)), reason...)
+}
+
+// parseWSClose decodes a close frame body. An empty body means no code.
+func parseWSClose(payload []byte) (int, string) {
+	if len(payload) < 2 {
+		return wsCloseNormal, ""
+	}
+	return int(binary.BigEndian.Uint16(payload)), string(payload[2:])
+}
@@ -0,0 +1,111 @@
+package infoservice
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWSAcceptKey(t *testing.T) {
+	// Example from RFC 6455, section 1.3.
+	if got := wsAcceptKey("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("wsAcceptKey = %s", got)
+	}
+}
+
+func TestWSFrame_RoundTrip(t *testing.T) {
+	for _, size := range []int{0, 125, 126, 65535, 65536} {
+		for _, mask := range []bool{false, true} {
+			payload := bytes.Repeat([]byte("x"), size)
+			var buf bytes.Buffer
+			if err := writeWSFrame(&buf, wsOpText, payload, mask); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			f, err := readWSFrame(bufio.NewReader(&buf), 1<<20)
+			if err != nil {
+				t.Fatalf("size %d mask %v: read: %v", size, mask, err)
+			}
+			if !f.fin || f.opcode != wsOpText || f.masked != mask || !bytes.Equal(f.payload, payload) {
+				t.Errorf("size %d mask %v: frame = fin %v op %d masked %v len %d", size, mask, f.fin, f.opcode, f.masked, len(f.payload))
+			}
+		}
+	}
+}
+
+func TestReadWSFrame_Violations(t *testing.T) {
+	testCases := []struct {
+		name  string
+		frame []byte
+		code  int
+	}{
+		{"reserved bits", []byte{0xC1, 0x80, 0, 0, 0, 0}, wsCloseProtocolError},
+		{"fragmented ping", []byte{0x09, 0x80, 0, 0, 0, 0}, wsCloseProtocolError},
+		{"long close frame", []byte{0x88, 0xFE, 0x00, 0x80}, wsCloseProtocolError},
+		{"too big", []byte{0x81, 0xFF, 0, 0, 0, 0, 0, 2, 0, 0}, wsCloseTooBig},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := readWSFrame(bufio.NewReader(bytes.NewReader(tc.frame)), wsMaxMessageBytes)
+			var ce *wsCloseError
+			if !errors.As(err, &ce) || ce.code != tc.code {
+				t.Errorf("err = %v, want close code %d", err, tc.code)
+			}
+		})
+	}
+}
+
+func TestWSClosePayload(t *testing.T) {
+	code, reason := parseWSClose(wsClosePayload(wsCloseGoingAway, "bye"))
+	if code != wsCloseGoingAway || reason != "bye" {
+		t.Errorf("parsed (%d, %q)", code, reason)
+	}
+	if code, _ := parseWSClose(nil); code != wsCloseNormal {
+		t.Errorf("empty payload code = %d, want %d", code, wsCloseNormal)
+	}
+	if p := wsClosePayload(wsCloseNormal, strings.Repeat("x", 200)); len(p) > 125 {
+		t.Errorf("close payload is %d bytes, control frames allow 125", len(p))
+	}
+}
+
+func TestCheckWSHandshake(t *testing.T) {
+	valid := map[string]string{
+		"Connection":            "keep-alive, Upgrade",
+		"Upgrade":               "websocket",
+		"Sec-WebSocket-Version": "13",
+		"Sec-WebSocket-Key":     "dGhlIHNhbXBsZSBub25jZQ==",
+	}
+	testCases := []struct {
+		name    string
+		change  map[string]string
+		wantErr string
+	}{
+		{"valid", nil, ""},
+		{"no upgrade", map[string]string{"Upgrade": ""}, "upgrade"},
+		{"old version", map[string]string{"Sec-WebSocket-Version": "8"}, "Version"},
+		{"short key", map[string]string{"Sec-WebSocket-Key": "c2hvcnQ="}, "Key"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/ws", nil)
+			for k, v := range valid {
+				req.Header.Set(k, v)
+			}
+			for k, v := range tc.change {
+				req.Header.Set(k, v)
+			}
+			key, err := checkWSHandshake(req)
+			if tc.wantErr == "" {
+				if err != nil || key != valid["Sec-WebSocket-Key"] {
+					t.Errorf("= (%q, %v), want the key", key, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("err = %v, want one mentioning %q", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,567 @@
+package infoservice
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// ==================== WEBSOCKET DIAGNOSTICS ====================
+
+// Clients talk to /ws in JSON text messages:
+//
+//	{"type": "subscribe", "topics": ["metrics", "logs", "health"]}
+//	{"type": "unsubscribe", "topics": ["logs"]}
+//	{"type": "ping", "id": "42"}
+//	{"type": "set-interval", "interval": "2s"}
+//
+// and receive "welcome", "subscribed", "pong", "interval", "error" replies
+// plus "metrics", "log" and "health" messages for their topics.
+
+var wsTopics = []string{"health", "logs", "metrics"}
+
+const (
+	wsMinInterval = 100 * time.Millisecond
+	wsQueueSize   = 64
+	// wsCloseGrace is how long the server waits for the client's reply to
+	// a close frame before dropping the connection.
+	wsCloseGrace = time.Second
+)
+
+type wsMessage struct {
+	Type     string      `json:"type"`
+	ID       string      `json:"id,omitempty"`
+	Topics   []string    `json:"topics,omitempty"`
+	Interval string      `json:"interval,omitempty"`
+	Time     string      `json:"time,omitempty"`
+	Message  string      `json:"message,omitempty"`
+	Data     interface{} `json:"data,omitempty"`
+}
+
+// wsHealth combines /health and /ready: Status and HealthReasons are what
+// /health reports, Ready and Reasons what /ready does.
+type wsHealth struct {
+	Status        string   `json:"status"`
+	HealthReasons []string `json:"health_reasons,omitempty"`
+	Ready         bool     `json:"ready"`
+	Reasons       []string `json:"reasons"`
+}
+
+var (
+	errWSShuttingDown = errors.New("Server is shutting down")
+	errWSTooMany      = errors.New("Too many WebSocket connections")
+)
+
+// wsHub owns the open /ws sessions.
+type wsHub struct {
+	uptime       func() time.Duration
+	health       func() HealthResp
+	now          func() time.Time
+	readiness    *readinessState
+	requests     *atomic.Uint64
+	log          *logger
+	maxConns     int
+	origins      []string
+	interval     time.Duration
+	pingInterval time.Duration
+	writeTimeout time.Duration
+
+	mu       sync.Mutex
+	sessions map[*wsSession]struct{}
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+// newWSHub builds the hub for srv, whose uptime, health and readiness its
+// sessions report.
+func newWSHub(srv *Server) *wsHub {
+	cfg := srv.cfg
+	return &wsHub{
+		uptime:       srv.elapsed,
+		health:       func() HealthResp { return srv.health(english) },
+		now:          srv.now,
+		readiness:    srv.readiness,
+		requests:     &srv.requests,
+		log:          srv.log,
+		maxConns:     cfg.WSMaxConns,
+		origins:      splitList(cfg.WSAllowedOrigins),
+		interval:     cfg.EventsInterval,
+		pingInterval: cfg.WSPingInterval,
+		writeTimeout: cfg.WriteTimeout,
+		sessions:     map[*wsSession]struct{}{},
+	}
+}
+
+// originAllowed accepts requests without an Origin (not from a browser),
+// same-origin requests and the origins listed in WS_ALLOWED_ORIGINS.
+func (h *wsHub) originAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	for _, allowed := range h.origins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *wsHub) add(s *wsSession) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return errWSShuttingDown
+	}
+	if h.maxConns > 0 && len(h.sessions) >= h.maxConns {
+		return errWSTooMany
+	}
+	h.sessions[s] = struct{}{}
+	h.wg.Add(1)
+	return nil
+}
+
+func (h *wsHub) remove(s *wsSession) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.sessions[s]; ok {
+		delete(h.sessions, s)
+		h.wg.Done()
+	}
+}
+
+// publishLog sends a log record to the sessions subscribed to logs. It is
+// a logger sink.
+func (h *wsHub) publishLog(rec logRecord) {
+	msg := wsMessage{Type: "log", Data: rec}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.sessions {
+		if s.subscribed("logs") {
+			s.sendJSON(msg)
+		}
+	}
+}
+
+// Close sends every session a "going away" close frame and waits briefly
+// for them to finish. Hijacked connections are invisible to
+// http.Server.Shutdown, so Server.Serve registers this with RegisterOnShutdown.
+func (h *wsHub) Close() {
+	h.mu.Lock()
+	h.closed = true
+	for s := range h.sessions {
+		s.close(wsCloseGoingAway, "server shutting down")
+	}
+	h.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		h.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * wsCloseGrace):
+	}
+}
+
+// wsFrameOut is a frame queued for the write loop.
+type wsFrameOut struct {
+	opcode  byte
+	payload []byte
+}
+
+// wsSession is one /ws connection. The handler goroutine reads, writeLoop
+// owns all writes and publishLoop produces metrics and health messages.
+type wsSession struct {
+	hub  *wsHub
+	conn net.Conn
+	br   *bufio.Reader
+
+	out        chan wsFrameOut
+	closing    chan []byte
+	closeSent  chan struct{}
+	intervalCh chan time.Duration
+	refresh    chan struct{}
+	done       chan struct{}
+	closeOnce  sync.Once
+	doneOnce   sync.Once
+
+	mu     sync.Mutex
+	topics map[string]bool
+}
+
+func newWSSession(h *wsHub) *wsSession {
+	return &wsSession{
+		hub:        h,
+		out:        make(chan wsFrameOut, wsQueueSize),
+		closing:    make(chan []byte, 1),
+		closeSent:  make(chan struct{}),
+		intervalCh: make(chan time.Duration, 1),
+		refresh:    make(chan struct{}, 1),
+		done:       make(chan struct{}),
+		topics:     map[string]bool{},
+	}
+}
+
+func (s *wsSession) subscribed(topic string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.topics[topic]
+}
+
+// sendJSON queues a message. Messages are dropped, not waited for, when the
+// client reads too slowly: metrics and logs are only useful live.
+func (s *wsSession) sendJSON(msg wsMessage) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	s.enqueue(wsOpText, data)
+}
+
+func (s *wsSession) enqueue(opcode byte, payload []byte) {
+	select {
+	case s.out <- wsFrameOut{opcode, payload}:
+	default:
+	}
+}
+
+// close starts the closing handshake; only the first call counts.
+func (s *wsSession) close(code int, reason string) {
+	s.closeOnce.Do(func() {
+		s.closing <- wsClosePayload(code, reason)
+	})
+}
+
+// terminate drops the connection and stops the session's goroutines.
+func (s *wsSession) terminate() {
+	s.doneOnce.Do(func() {
+		close(s.done)
+		if s.conn != nil {
+			s.conn.Close()
+		}
+	})
+}
+
+func (s *wsSession) write(opcode byte, payload []byte) error {
+	var deadline time.Time
+	if s.hub.writeTimeout > 0 {
+		deadline = time.Now().Add(s.hub.writeTimeout)
+	}
+	s.conn.SetWriteDeadline(deadline)
+	return writeWSFrame(s.conn, opcode, payload, false)
+}
+
+// run serves the session and leaves the hub only once all its goroutines
+// have stopped.
+func (s *wsSession) run() {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		s.writeLoop()
+	}()
+	go func() {
+		defer wg.Done()
+		s.publishLoop()
+	}()
+
+	s.sendJSON(wsMessage{Type: "welcome", Topics: wsTopics, Interval: s.hub.interval.String()})
+	s.readLoop()
+
+	s.terminate()
+	wg.Wait()
+	s.hub.remove(s)
+}
+
+func (s *wsSession) writeLoop() {
+	ping := time.NewTicker(s.hub.pingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case f := <-s.out:
+			if err := s.write(f.opcode, f.payload); err != nil {
+				s.terminate()
+				return
+			}
+		case <-ping.C:
+			if err := s.write(wsOpPing, nil); err != nil {
+				s.terminate()
+				return
+			}
+		case payload := <-s.closing:
+			s.write(wsOpClose, payload)
+			close(s.closeSent)
+			select {
+			case <-s.done:
+			case <-time.After(wsCloseGrace):
+				s.terminate()
+			}
+			return
+		}
+	}
+}
+
+func (s *wsSession) publishLoop() {
+	sampler := newStatsSampler(s.hub.uptime, s.hub.now, s.hub.readiness, s.hub.requests)
+	ticker := time.NewTicker(s.hub.interval)
+	defer ticker.Stop()
+	_, _, changed := s.hub.readiness.Status()
+	status := s.hub.health().Status
+
+	// Readiness signals its changes; the health status is polled on each
+	// tick.
+	sendHealth := func() {
+		ready, reasons, ch := s.hub.readiness.Status()
+		health := s.hub.health()
+		changed, status = ch, health.Status
+		if s.subscribed("health") {
+			s.sendJSON(wsMessage{Type: "health", Data: wsHealth{Status: health.Status, HealthReasons: health.Reasons, Ready: ready, Reasons: reasons}})
+		}
+	}
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case d := <-s.intervalCh:
+			ticker.Reset(d)
+		case <-ticker.C:
+			if s.subscribed("metrics") {
+				s.sendJSON(wsMessage{Type: "metrics", Data: sampler.sample()})
+			}
+			if s.hub.health().Status != status {
+				sendHealth()
+			}
+		case <-changed:
+			sendHealth()
+		case <-s.refresh:
+			// A new subscription gets data at once instead of on the next tick.
+			if s.subscribed("metrics") {
+				s.sendJSON(wsMessage{Type: "metrics", Data: sampler.sample()})
+			}
+			sendHealth()
+		}
+	}
+}
+
+// readLoop reads until the connection ends. Any frame, pongs included,
+// extends the read deadline, so a client that stops answering pings is
+// dropped after two ping intervals.
+func (s *wsSession) readLoop() {
+	var message []byte
+	inMessage, closing := false, false
+
+	fail := func(code int, reason string) {
+		s.close(code, reason)
+		closing = true
+	}
+
+	for {
+		s.conn.SetReadDeadline(time.Now().Add(2 * s.hub.pingInterval))
+		f, err := readWSFrame(s.br, wsMaxMessageBytes)
+		if err != nil {
+			var ce *wsCloseError
+			if errors.As(err, &ce) && !closing {
+				// The rest of the stream cannot be parsed, so do not wait
+				// for the client's reply.
+				s.close(ce.code, ce.reason)
+				s.waitCloseSent()
+			}
+			return
+		}
+		if closing {
+			if f.opcode == wsOpClose {
+				return
+			}
+			continue
+		}
+		if !f.masked {
+			fail(wsCloseProtocolError, "client frames must be masked")
+			continue
+		}
+
+		switch f.opcode {
+		case wsOpPing:
+			s.enqueue(wsOpPong, f.payload)
+		case wsOpPong:
+		case wsOpClose:
+			// Echo the code; the server then closes the TCP connection
+			// first, as RFC 6455 recommends.
+			code, _ := parseWSClose(f.payload)
+			s.close(code, "")
+			s.waitCloseSent()
+			return
+		case wsOpBinary:
+			fail(wsCloseUnsupportedData, "binary messages are not supported")
+		case wsOpText, wsOpContinuation:
+			if (f.opcode == wsOpText) == inMessage {
+				fail(wsCloseProtocolError, "unexpected continuation frame")
+				continue
+			}
+			if len(message)+len(f.payload) > wsMaxMessageBytes {
+				fail(wsCloseTooBig, "message too big")
+				continue
+			}
+			message = append(message, f.payload...)
+			inMessage = !f.fin
+			if inMessage {
+				continue
+			}
+			if !utf8.Valid(message) {
+				fail(wsCloseInvalidPayload, "text message is not valid UTF-8")
+				continue
+			}
+			s.handle(message)
+			message = nil
+		default:
+			fail(wsCloseProtocolError, "unknown opcode")
+		}
+	}
+}
+
+func (s *wsSession) waitCloseSent() {
+	select {
+	case <-s.closeSent:
+	case <-s.done:
+	}
+}
+
+// handle runs one client command.
+func (s *wsSession) handle(data []byte) {
+	var msg wsMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		s.sendJSON(wsMessage{Type: "error", Message: "invalid JSON: " + err.Error()})
+		return
+	}
+
+	switch msg.Type {
+	case "subscribe", "unsubscribe":
+		for _, topic := range msg.Topics {
+			if !containsString(wsTopics, topic) {
+				s.sendJSON(wsMessage{Type: "error", Message: "unknown topic " + topic + ", valid: " + strings.Join(wsTopics, ", ")})
+				return
+			}
+		}
+		s.mu.Lock()
+		for _, topic := range msg.Topics {
+			if msg.Type == "subscribe" {
+				s.topics[topic] = true
+			} else {
+				delete(s.topics, topic)
+			}
+		}
+		current := make([]string, 0, len(s.topics))
+		for topic := range s.topics {
+			current = append(current, topic)
+		}
+		s.mu.Unlock()
+		sort.Strings(current)
+
+		s.sendJSON(wsMessage{Type: "subscribed", Topics: current})
+		if msg.Type == "subscribe" {
+			select {
+			case s.refresh <- struct{}{}:
+			default:
+			}
+		}
+	case "ping":
+		s.sendJSON(wsMessage{Type: "pong", ID: msg.ID, Time: s.hub.now().UTC().Format(time.RFC3339Nano)})
+	case "set-interval":
+		d, err := time.ParseDuration(msg.Interval)
+		if err != nil || d < wsMinInterval {
+			s.sendJSON(wsMessage{Type: "error", Message: "interval must be a duration of at least " + wsMinInterval.String()})
+			return
+		}
+		select {
+		case <-s.intervalCh: // replace a change the publisher has not seen yet
+		default:
+		}
+		s.intervalCh <- d
+		s.sendJSON(wsMessage{Type: "interval", Interval: d.String()})
+	default:
+		s.sendJSON(wsMessage{Type: "error", Message: "unknown message type " + msg.Type})
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) wsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, r, http.StatusMethodNotAllowed, "Only GET method is allowed for this endpoint")
+		return
+	}
+	h := s.diagnostics
+	if h == nil {
+		writeJSONError(w, r, http.StatusServiceUnavailable, "WebSocket endpoint is not available")
+		return
+	}
+	if !headerContainsToken(r.Header, "Upgrade", "websocket") {
+		w.Header().Set("Upgrade", "websocket")
+		writeJSONError(w, r, http.StatusUpgradeRequired, "This endpoint speaks WebSocket")
+		return
+	}
+	key, err := checkWSHandshake(r)
+	if err != nil {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		writeJSONError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !h.originAllowed(r) {
+		writeJSONError(w, r, http.StatusForbidden, "Origin not allowed")
+		return
+	}
+
+	sess := newWSSession(h)
+	if err := h.add(sess); err != nil {
+		writeJSONError(w, r, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+
+	conn, brw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		h.remove(sess)
+		h.log.errorf("ws", "WebSocket hijack failed: %v", err)
+		writeJSONError(w, r, http.StatusInternalServerError, "WebSocket upgrade failed")
+		return
+	}
+	// The server's read and write timeouts still apply to the hijacked
+	// connection; the session sets its own deadlines instead.
+	conn.SetDeadline(time.Time{})
+	brw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + wsAcceptKey(key) + "\r\n\r\n")
+	if err := brw.Flush(); err != nil {
+		h.remove(sess)
+		conn.Close()
+		return
+	}
+
+	sess.conn, sess.br = conn, brw.Reader
+	sess.run()
+}
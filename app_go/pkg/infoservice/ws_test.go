@@ -0,0 +1,388 @@
+package infoservice
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withWSHub builds a Server from cfg and opts and serves its /ws through
+// the full handler chain, compression included. It returns the server and
+// its address.
+func withWSHub(t *testing.T, cfg Config, opts ...Option) (*Server, string) {
+	t.Helper()
+	s := newTestServer(t, append([]Option{WithConfig(cfg)}, opts...)...)
+	return s, serveWS(t, s)
+}
+
+// serveWS gives s its hub and serves it, for tests that set s up first.
+func serveWS(t *testing.T, s *Server) string {
+	t.Helper()
+	s.diagnostics = newWSHub(s)
+	srv := httptest.NewServer(s.Handler())
+	t.Cleanup(func() {
+		s.diagnostics.Close()
+		srv.Close()
+	})
+	return srv.Listener.Addr().String()
+}
+
+type wsTestClient struct {
+	t    *testing.T
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+// dialWS performs the handshake by hand and returns the response; the
+// client is nil unless the server switched protocols.
+func dialWS(t *testing.T, addr string, header map[string]string) (*wsTestClient, *http.Response) {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	req := "GET /ws HTTP/1.1\r\nHost: " + addr + "\r\n" +
+		"Connection: Upgrade\r\nUpgrade: websocket\r\n" +
+		"Sec-WebSocket-Version: 13\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n" +
+		"Accept-Encoding: gzip\r\n"
+	for k, v := range header {
+		req += k + ": " + v + "\r\n"
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Write([]byte(req + "\r\n")); err != nil {
+		t.Fatalf("write handshake: %v", err)
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatalf("read handshake: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return nil, resp
+	}
+	if got := resp.Header.Get("Sec-WebSocket-Accept"); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("Sec-WebSocket-Accept = %q", got)
+	}
+	return &wsTestClient{t: t, conn: conn, br: br}, resp
+}
+
+func (c *wsTestClient) send(v interface{}) {
+	c.t.Helper()
+	data, _ := json.Marshal(v)
+	if err := writeWSFrame(c.conn, wsOpText, data, true); err != nil {
+		c.t.Fatalf("send: %v", err)
+	}
+}
+
+// frame reads the next raw frame.
+func (c *wsTestClient) frame() wsFrame {
+	c.t.Helper()
+	c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	f, err := readWSFrame(c.br, 1<<20)
+	if err != nil {
+		c.t.Fatalf("read frame: %v", err)
+	}
+	return f
+}
+
+// expect reads messages until one of type typ arrives, skipping pings and
+// other messages.
+func (c *wsTestClient) expect(typ string) wsMessage {
+	c.t.Helper()
+	for {
+		f := c.frame()
+		if f.opcode == wsOpClose {
+			code, reason := parseWSClose(f.payload)
+			c.t.Fatalf("waiting for %s: connection closed (%d %s)", typ, code, reason)
+		}
+		if f.opcode != wsOpText {
+			continue
+		}
+		var msg wsMessage
+		if err := json.Unmarshal(f.payload, &msg); err != nil {
+			c.t.Fatalf("invalid message %q: %v", f.payload, err)
+		}
+		if msg.Type == typ {
+			return msg
+		}
+	}
+}
+
+// expectClose reads until a close frame and returns its code.
+func (c *wsTestClient) expectClose() int {
+	c.t.Helper()
+	for {
+		if f := c.frame(); f.opcode == wsOpClose {
+			code, _ := parseWSClose(f.payload)
+			return code
+		}
+	}
+}
+
+func TestWS_WelcomeAndPing(t *testing.T) {
+	_, addr := withWSHub(t, DefaultConfig())
+	c, _ := dialWS(t, addr, nil)
+
+	welcome := c.expect("welcome")
+	if strings.Join(welcome.Topics, ",") != "health,logs,metrics" || welcome.Interval != "5s" {
+		t.Errorf("welcome = %+v", welcome)
+	}
+
+	c.send(wsMessage{Type: "ping", ID: "7"})
+	if pong := c.expect("pong"); pong.ID != "7" || pong.Time == "" {
+		t.Errorf("pong = %+v, want id 7 and a time", pong)
+	}
+}
+
+func TestWS_MetricsAndSetInterval(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.EventsInterval = time.Hour
+	_, addr := withWSHub(t, cfg)
+	c, _ := dialWS(t, addr, nil)
+
+	c.send(wsMessage{Type: "subscribe", Topics: []string{"metrics"}})
+	if sub := c.expect("subscribed"); strings.Join(sub.Topics, ",") != "metrics" {
+		t.Errorf("subscribed = %v", sub.Topics)
+	}
+	// The first sample comes right away, not after the hour-long interval.
+	first := c.expect("metrics")
+	data, _ := first.Data.(map[string]interface{})
+	if data["goroutines"] == nil || data["heap_alloc_bytes"] == nil {
+		t.Errorf("metrics data = %v", first.Data)
+	}
+
+	c.send(wsMessage{Type: "set-interval", Interval: "1ms"})
+	if e := c.expect("error"); !strings.Contains(e.Message, "at least") {
+		t.Errorf("error = %q", e.Message)
+	}
+	c.send(wsMessage{Type: "set-interval", Interval: "100ms"})
+	if iv := c.expect("interval"); iv.Interval != "100ms" {
+		t.Errorf("interval = %q, want 100ms", iv.Interval)
+	}
+	c.expect("metrics")
+	c.expect("metrics")
+}
+
+func TestWS_HealthTopic(t *testing.T) {
+	s, addr := withWSHub(t, DefaultConfig())
+	c, _ := dialWS(t, addr, nil)
+
+	c.send(wsMessage{Type: "subscribe", Topics: []string{"health"}})
+	if h := c.expect("health"); h.Data.(map[string]interface{})["ready"] != true {
+		t.Errorf("initial health = %v, want ready", h.Data)
+	}
+
+	s.readiness.Set("test", "Draining")
+	h := c.expect("health").Data.(map[string]interface{})
+	if h["ready"] != false || h["reasons"].([]interface{})[0] != "Draining" {
+		t.Errorf("health after change = %v", h)
+	}
+}
+
+func TestWS_HealthTopic_Degraded(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.EventsInterval = 20 * time.Millisecond
+	s := newTestServer(t, WithConfig(cfg))
+	sync := withTimeSync(s, startNTPStandIn(t, 3*time.Second, nil), time.Second)
+	addr := serveWS(t, s)
+	c, _ := dialWS(t, addr, nil)
+
+	c.send(wsMessage{Type: "subscribe", Topics: []string{"health"}})
+	if h := c.expect("health").Data.(map[string]interface{}); h["status"] != "healthy" {
+		t.Errorf("initial health = %v, want healthy", h)
+	}
+
+	// The same clock skew that degrades /health reaches the socket on the
+	// next tick.
+	if err := sync.sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	h := c.expect("health").Data.(map[string]interface{})
+	if h["status"] != "degraded" || h["ready"] != true || len(h["health_reasons"].([]interface{})) != 1 {
+		t.Errorf("health after skew = %v", h)
+	}
+}
+
+func TestWS_LogsTopic(t *testing.T) {
+	s, addr := withWSHub(t, DefaultConfig())
+	hub := s.diagnostics
+	defer s.log.addSink(hub.publishLog)()
+	c, _ := dialWS(t, addr, nil)
+
+	hub.publishLog(logRecord{Message: "not subscribed yet"})
+	c.send(wsMessage{Type: "subscribe", Topics: []string{"logs"}})
+	c.expect("subscribed")
+	s.log.infof("http", "Request: GET /")
+
+	if line := c.expect("log").Data.(map[string]interface{}); line["message"] != "Request: GET /" || line["level"] != "info" || line["component"] != "http" {
+		t.Errorf("log = %v", line)
+	}
+
+	c.send(wsMessage{Type: "unsubscribe", Topics: []string{"logs"}})
+	if sub := c.expect("subscribed"); len(sub.Topics) != 0 {
+		t.Errorf("topics after unsubscribe = %v", sub.Topics)
+	}
+}
+
+func TestWS_BadCommands(t *testing.T) {
+	_, addr := withWSHub(t, DefaultConfig())
+	c, _ := dialWS(t, addr, nil)
+
+	for _, tc := range []struct {
+		msg  interface{}
+		want string
+	}{
+		{wsMessage{Type: "subscribe", Topics: []string{"gossip"}}, "unknown topic gossip"},
+		{wsMessage{Type: "reboot"}, "unknown message type"},
+		{"not an object", "invalid JSON"},
+	} {
+		c.send(tc.msg)
+		if e := c.expect("error"); !strings.Contains(e.Message, tc.want) {
+			t.Errorf("error = %q, want %q", e.Message, tc.want)
+		}
+	}
+}
+
+func TestWS_OriginCheck(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.WSAllowedOrigins = "https://dashboard.example"
+	_, addr := withWSHub(t, cfg)
+
+	testCases := []struct {
+		origin string
+		want   int
+	}{
+		{"", http.StatusSwitchingProtocols},
+		{"http://" + addr, http.StatusSwitchingProtocols},
+		{"https://dashboard.example", http.StatusSwitchingProtocols},
+		{"https://evil.example", http.StatusForbidden},
+	}
+	for _, tc := range testCases {
+		header := map[string]string{}
+		if tc.origin != "" {
+			header["Origin"] = tc.origin
+		}
+		if _, resp := dialWS(t, addr, header); resp.StatusCode != tc.want {
+			t.Errorf("Origin %q: status = %d, want %d", tc.origin, resp.StatusCode, tc.want)
+		}
+	}
+}
+
+func TestWS_ConnectionLimit(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.WSMaxConns = 1
+	_, addr := withWSHub(t, cfg)
+
+	first, _ := dialWS(t, addr, nil)
+	if first == nil {
+		t.Fatal("first connection refused")
+	}
+	if _, resp := dialWS(t, addr, nil); resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("second connection: status = %d, want 503", resp.StatusCode)
+	}
+
+	// A closed connection frees its slot.
+	first.conn.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if c, _ := dialWS(t, addr, nil); c != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("slot not freed after the first client disconnected")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestWS_Keepalive(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.WSPingInterval = 50 * time.Millisecond
+	_, addr := withWSHub(t, cfg)
+	c, _ := dialWS(t, addr, nil)
+
+	for {
+		if f := c.frame(); f.opcode == wsOpPing {
+			break
+		}
+	}
+
+	// A client that never answers is dropped after two ping intervals.
+	c.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		if _, err := readWSFrame(c.br, 1<<20); err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				t.Fatal("silent client was not disconnected")
+			}
+			return
+		}
+	}
+}
+
+func TestWS_CloseHandshake(t *testing.T) {
+	_, addr := withWSHub(t, DefaultConfig())
+	c, _ := dialWS(t, addr, nil)
+
+	writeWSFrame(c.conn, wsOpClose, wsClosePayload(wsCloseNormal, "bye"), true)
+	if code := c.expectClose(); code != wsCloseNormal {
+		t.Errorf("echoed close code = %d, want %d", code, wsCloseNormal)
+	}
+}
+
+func TestWS_ProtocolErrors(t *testing.T) {
+	_, addr := withWSHub(t, DefaultConfig())
+
+	unmasked, _ := dialWS(t, addr, nil)
+	writeWSFrame(unmasked.conn, wsOpText, []byte(`{"type":"ping"}`), false)
+	if code := unmasked.expectClose(); code != wsCloseProtocolError {
+		t.Errorf("unmasked frame: close code = %d, want %d", code, wsCloseProtocolError)
+	}
+
+	binary, _ := dialWS(t, addr, nil)
+	writeWSFrame(binary.conn, wsOpBinary, []byte{1, 2, 3}, true)
+	if code := binary.expectClose(); code != wsCloseUnsupportedData {
+		t.Errorf("binary frame: close code = %d, want %d", code, wsCloseUnsupportedData)
+	}
+}
+
+func TestWS_ShutdownClosesConnections(t *testing.T) {
+	s, addr := withWSHub(t, DefaultConfig())
+	c, _ := dialWS(t, addr, nil)
+	c.expect("welcome")
+
+	go s.diagnostics.Close()
+	if code := c.expectClose(); code != wsCloseGoingAway {
+		t.Errorf("close code = %d, want %d", code, wsCloseGoingAway)
+	}
+	writeWSFrame(c.conn, wsOpClose, wsClosePayload(wsCloseGoingAway, ""), true)
+
+	if _, resp := dialWS(t, addr, nil); resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("after shutdown: status = %d, want 503", resp.StatusCode)
+	}
+}
+
+func TestWSHandler_Errors(t *testing.T) {
+	if w := serveGet(newTestServer(t).wsHandler, "/ws", nil); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("without a hub: status = %d, want 503", w.Code)
+	}
+
+	s, _ := withWSHub(t, DefaultConfig())
+	w := serveGet(s.wsHandler, "/ws", nil)
+	if w.Code != http.StatusUpgradeRequired || w.Header().Get("Upgrade") != "websocket" {
+		t.Errorf("plain GET: status = %d, Upgrade = %q, want 426 websocket", w.Code, w.Header().Get("Upgrade"))
+	}
+	w = httptest.NewRecorder()
+	s.wsHandler(w, httptest.NewRequest("POST", "/ws", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: status = %d, want 405", w.Code)
+	}
+}
@@ -106,6 +106,11 @@ devops_info_endpoint_calls = Counter(
 HOST = os.getenv("HOST", "0.0.0.0")
 PORT = int(os.getenv("PORT", 8000))
 
+# Deployment identity, the same variables app_go reads
+DEPLOY_COLOR = os.getenv("DEPLOY_COLOR", "")
+DEPLOY_TRACK = os.getenv("DEPLOY_TRACK", "stable")
+DEPLOY_REVISION = os.getenv("DEPLOY_REVISION", "")
+
 logger.info(f"Application starting - Host: {HOST}, Port: {PORT}")
 
 
@@ -117,6 +122,16 @@ def get_uptime():
     return {"seconds": secs, "human": f"{hrs} hours, {mins} minutes"}
 
 
+def get_deployment():
+    """Which blue/green stack or rollout track serves; unset values are left out."""
+    deployment = {"track": DEPLOY_TRACK}
+    if DEPLOY_COLOR:
+        deployment = {"color": DEPLOY_COLOR, **deployment}
+    if DEPLOY_REVISION:
+        deployment["revision"] = DEPLOY_REVISION
+    return deployment
+
+
 @app.on_event("startup")
 async def startup_event():
     logger.info("FastAPI application startup complete")
@@ -218,6 +233,7 @@ def root(request: Request):
             "description": "DevOps course info service",
             "framework": "FastAPI",
         },
+        "deployment": get_deployment(),
         "system": {
             "hostname": socket.gethostname(),
             "platform": platform.system(),
@@ -231,6 +247,7 @@ def root(request: Request):
             "uptime_human": uptime["human"],
             "current_time": datetime.now(timezone.utc).isoformat(),
             "timezone": "UTC",
+            "utc_offset": "+00:00",
         },
         "request": {
             "client_ip": request.client.host if request.client else "unknown",
@@ -105,6 +105,9 @@ def test_root_endpoint_runtime_info():
     except ValueError:
         pytest.fail("current_time is not in valid ISO format")
 
+    # current_time is UTC, and utc_offset says so
+    assert runtime_info["utc_offset"] == "+00:00"
+
     # Verify uptime human format
     assert "hours" in runtime_info["uptime_human"]
     assert "minutes" in runtime_info["uptime_human"]
@@ -133,6 +136,14 @@ def test_root_endpoint_request_info():
     assert request_info["client_ip"] in ["127.0.0.1", "testclient"] or ":" in request_info["client_ip"]
 
 
+def test_root_endpoint_deployment_info():
+    """Test deployment identity in root endpoint; unset color and revision are left out."""
+    response = client.get("/")
+    data = response.json()
+
+    assert data["deployment"] == {"track": "stable"}
+
+
 def test_root_endpoint_endpoints_list():
     """Test endpoints list in root endpoint."""
     response = client.get("/")
@@ -0,0 +1,25 @@
+#!/bin/sh
+# Re-records the app_python fixtures used by app_go/pkg/infoservice/contract_test.go.
+#
+#   docker compose up -d app           # or: cd app_python && python app.py
+#   ./contract/record_python.sh http://localhost:8000
+#
+# Review the diff before committing: a changed key set is exactly the
+# drift the contract tests exist to catch.
+set -eu
+
+BASE_URL="${1:-http://localhost:8000}"
+OUT="$(dirname "$0")/fixtures/python"
+
+record() {
+    name="$1"
+    shift
+    curl -s "$@" | python3 -m json.tool --indent 2 > "$OUT/$name.json"
+    echo "recorded $name.json"
+}
+
+record root "$BASE_URL/"
+record health "$BASE_URL/health"
+record visits "$BASE_URL/visits"
+record error_404 "$BASE_URL/nope"
+record error_405 -X POST "$BASE_URL/health"